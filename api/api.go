@@ -2,11 +2,10 @@ package api
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"math"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
@@ -15,6 +14,8 @@ import (
 // API is an interface to be implemented by the client that connects to it to interact with router data location API
 type API interface {
 	GetRouterLocationData(ctx context.Context) (*RouterLocationData, error)
+	// StreamRouterLocationData passes each router and location to the handler as it's decoded
+	StreamRouterLocationData(ctx context.Context, h Handler) error
 }
 
 // Option specifies a builder function for configuring an APIs client
@@ -29,7 +30,9 @@ type Client struct {
 // this is a check to confirm the implementation is compatible with dependent interfaces
 var _ API = (*Client)(nil)
 
-// New initializes the api's client
+// New initializes the api's client.
+// A base URL with the file scheme, e.g. file:///tmp/db.json, a path such as /tmp/db.json or "-" for stdin returns a
+// FileSource instead
 func New(opts ...Option) API {
 	client := &Client{}
 
@@ -37,6 +40,13 @@ func New(opts ...Option) API {
 		opt(client)
 	}
 
+	if path, ok := filePath(client.options.baseURL); ok {
+		source := NewFileSource(path)
+		source.maxBodySize = client.options.maxBodySize
+
+		return source
+	}
+
 	retryClient := retryablehttp.NewClient()
 	retryClient.RetryMax = client.options.maxRetries
 	retryClient.HTTPClient.Timeout = client.options.timeout
@@ -67,7 +77,40 @@ func New(opts ...Option) API {
 
 // GetRouterLocationData makes the call to the API and retrieves JSON data and transforms it to our models structs
 func (c *Client) GetRouterLocationData(ctx context.Context) (*RouterLocationData, error) {
-	req, err := c.prepareGetRequest(ctx, c.options.baseURL)
+	var data collector
+	if err := c.StreamRouterLocationData(ctx, &data); err != nil {
+		return nil, err
+	}
+
+	return &data.data, nil
+}
+
+// StreamRouterLocationData makes the call to the API, decoding the JSON response body a router or location at a time.
+// When a page limit is set the routers and locations collections are fetched page by page instead of the combined document
+func (c *Client) StreamRouterLocationData(ctx context.Context, h Handler) error {
+	if c.options.pageLimit > 0 {
+		return c.streamPaginatedRouterLocationData(ctx, h)
+	}
+
+	resp, err := c.get(ctx, c.options.baseURL)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if err := decodeRouterLocationData(limitBody(resp.Body, c.options.maxBodySize), h); err != nil {
+		return fmt.Errorf("unmarshal response body %w", err)
+	}
+
+	return nil
+}
+
+// get performs a GET request returning the response, the caller is responsible for closing its body
+func (c *Client) get(ctx context.Context, requestURL string) (*http.Response, error) {
+	req, err := c.prepareGetRequest(ctx, requestURL)
 	if err != nil {
 		return nil, err
 	}
@@ -79,26 +122,40 @@ func (c *Client) GetRouterLocationData(ctx context.Context) (*RouterLocationData
 		return nil, fmt.Errorf("performing get router location data request: %s", err)
 	}
 
-	defer func() {
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusTooManyRequests {
 		_ = resp.Body.Close()
-	}()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusTooManyRequests {
 		return nil, fmt.Errorf("unexpected response status code: %d", resp.StatusCode)
 	}
 
-	// transform response body
-	body, err := io.ReadAll(resp.Body)
+	return resp, nil
+}
+
+// filePath returns the local path for base URLs pointing at a file or stdin, a base URL without a scheme being a path
+func filePath(baseURL string) (string, bool) {
+	if baseURL == _stdinPath {
+		return _stdinPath, true
+	}
+
+	u, err := url.Parse(baseURL)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read response body %s", err)
+		return "", false
+	}
+
+	if u.Scheme == "" && baseURL != "" {
+		return baseURL, true
+	}
+
+	if u.Scheme != "file" {
+		return "", false
 	}
 
-	var rLocData RouterLocationData
-	if err := json.Unmarshal(body, &rLocData); err != nil {
-		return nil, fmt.Errorf("unmarshal response body %s", err)
+	// file:db.json is parsed as opaque rather than having a path
+	if u.Opaque != "" {
+		return u.Opaque, true
 	}
 
-	return &rLocData, nil
+	return u.Path, true
 }
 
 // prepareGetRequest helper function to define the get http request
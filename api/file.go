@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// _stdinPath is the path used to read router location data from stdin
+const _stdinPath = "-"
+
+// FileSource is an implementation of the API interface reading router location data from a local file or stdin
+type FileSource struct {
+	path        string
+	stdin       io.Reader
+	maxBodySize int64
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ API = (*FileSource)(nil)
+
+// NewFileSource initializes a source reading the JSON document at path, or stdin when path is "-"
+func NewFileSource(path string) *FileSource {
+	return &FileSource{
+		path:  path,
+		stdin: os.Stdin,
+	}
+}
+
+// GetRouterLocationData reads the JSON document, which has the same shape as the API's response body
+func (f *FileSource) GetRouterLocationData(ctx context.Context) (*RouterLocationData, error) {
+	var data collector
+	if err := f.StreamRouterLocationData(ctx, &data); err != nil {
+		return nil, err
+	}
+
+	return &data.data, nil
+}
+
+// StreamRouterLocationData reads the JSON document a router or location at a time
+func (f *FileSource) StreamRouterLocationData(ctx context.Context, h Handler) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r := f.stdin
+	if f.path != _stdinPath {
+		file, err := os.Open(f.path)
+		if err != nil {
+			return fmt.Errorf("open router location data file: %w", err)
+		}
+
+		defer func() {
+			_ = file.Close()
+		}()
+
+		r = file
+	}
+
+	if err := decodeRouterLocationData(limitBody(r, f.maxBodySize), h); err != nil {
+		return fmt.Errorf("decode router location data file %s: %w", f.path, err)
+	}
+
+	return nil
+}
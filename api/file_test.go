@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const _testFileData = `{
+  "routers": [
+    {
+      "id": 1,
+      "name": "citadel-01",
+      "location_id": 1,
+      "router_links": [
+        1
+      ]
+    }
+  ],
+  "locations": [
+    {
+      "id": 1,
+      "postcode": "BE12 2ND",
+      "name": "Birmingham Motorcycle Museum"
+    }
+  ]
+}`
+
+func TestFileSource_GetRouterLocationData(t *testing.T) {
+	ctx := context.Background()
+
+	dir := t.TempDir()
+
+	validPath := filepath.Join(dir, "db.json")
+	assert.NoError(t, os.WriteFile(validPath, []byte(_testFileData), 0o600))
+
+	malformedPath := filepath.Join(dir, "malformed.json")
+	assert.NoError(t, os.WriteFile(malformedPath, []byte(`{"routers": [`), 0o600))
+
+	want := &RouterLocationData{
+		Routers: []Router{
+			{
+				ID:          1,
+				Name:        "citadel-01",
+				LocationID:  1,
+				RouterLinks: []int{1},
+			},
+		},
+		Locations: []Location{
+			{
+				ID:       1,
+				Postcode: "BE12 2ND",
+				Name:     "Birmingham Motorcycle Museum",
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		source  *FileSource
+		want    *RouterLocationData
+		wantErr string
+	}{
+		{
+			name:   "reads router location data from a file",
+			source: NewFileSource(validPath),
+			want:   want,
+		},
+		{
+			name:   "reads router location data from stdin",
+			source: &FileSource{path: "-", stdin: strings.NewReader(_testFileData)},
+			want:   want,
+		},
+		{
+			name:    "returns an error when the file doesn't exist",
+			source:  NewFileSource(filepath.Join(dir, "missing.json")),
+			wantErr: "open router location data file: open " + filepath.Join(dir, "missing.json") + ": no such file or directory",
+		},
+		{
+			name:    "returns an error when json is malformed",
+			source:  NewFileSource(malformedPath),
+			wantErr: "decode router location data file " + malformedPath + ": unexpected end of JSON input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.GetRouterLocationData(ctx)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNew_dispatchesOnScheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		want    API
+	}{
+		{
+			name:    "file url returns a file source",
+			baseURL: "file:///tmp/db.json",
+			want:    &FileSource{path: "/tmp/db.json", stdin: os.Stdin},
+		},
+		{
+			name:    "relative file url returns a file source",
+			baseURL: "file:db.json",
+			want:    &FileSource{path: "db.json", stdin: os.Stdin},
+		},
+		{
+			name:    "path without a scheme returns a file source",
+			baseURL: "/tmp/db.json",
+			want:    &FileSource{path: "/tmp/db.json", stdin: os.Stdin},
+		},
+		{
+			name:    "relative path without a scheme returns a file source",
+			baseURL: "testdata/db.json",
+			want:    &FileSource{path: "testdata/db.json", stdin: os.Stdin},
+		},
+		{
+			name:    "dash returns a stdin source",
+			baseURL: "-",
+			want:    &FileSource{path: "-", stdin: os.Stdin},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, New(WithBaseURL(tt.baseURL)))
+		})
+	}
+
+	_, ok := New(WithBaseURL("https://my-json-server.typicode.com/marcuzh/router_location_test_api/db")).(*Client)
+	assert.True(t, ok)
+}
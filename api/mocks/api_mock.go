@@ -49,3 +49,17 @@ func (mr *MockAPIMockRecorder) GetRouterLocationData(ctx interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRouterLocationData", reflect.TypeOf((*MockAPI)(nil).GetRouterLocationData), ctx)
 }
+
+// StreamRouterLocationData mocks base method.
+func (m *MockAPI) StreamRouterLocationData(ctx context.Context, h api.Handler) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamRouterLocationData", ctx, h)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamRouterLocationData indicates an expected call of StreamRouterLocationData.
+func (mr *MockAPIMockRecorder) StreamRouterLocationData(ctx, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamRouterLocationData", reflect.TypeOf((*MockAPI)(nil).StreamRouterLocationData), ctx, h)
+}
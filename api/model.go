@@ -19,6 +19,8 @@ type RouterLocationData struct {
 }
 
 type RouterLocationLink struct {
-	UniqueID   string // sort two locations alphabetically and concatenate them
-	Connection string
+	UniqueID      string `json:"unique_id"` // sort two locations alphabetically and concatenate them
+	Connection    string `json:"connection"`
+	SourceID      int    `json:"source_id"`
+	DestinationID int    `json:"destination_id"`
 }
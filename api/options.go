@@ -5,9 +5,11 @@ import (
 )
 
 type options struct {
-	baseURL    string
-	timeout    time.Duration
-	maxRetries int
+	baseURL     string
+	timeout     time.Duration
+	maxRetries  int
+	pageLimit   int
+	maxBodySize int64
 }
 
 // WithBaseURL sets base URL path for requests
@@ -30,3 +32,18 @@ func WithMaxRetries(retries int) Option {
 		a.(*Client).options.maxRetries = retries
 	}
 }
+
+// WithPageLimit fetches the routers and locations collections separately, requesting pages of the given size.
+// The base URL is then treated as the API root, with a trailing /db document path ignored
+func WithPageLimit(limit int) Option {
+	return func(a API) {
+		a.(*Client).options.pageLimit = limit
+	}
+}
+
+// WithMaxBodySize fails requests whose response body is larger than max bytes, zero or less disables the guard
+func WithMaxBodySize(max int64) Option {
+	return func(a API) {
+		a.(*Client).options.maxBodySize = max
+	}
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	_routersCollection   = "routers"
+	_locationsCollection = "locations"
+)
+
+// streamPaginatedRouterLocationData fetches the routers and locations collections, passing each item to the handler
+func (c *Client) streamPaginatedRouterLocationData(ctx context.Context, h Handler) error {
+	if err := streamCollection(ctx, c, _routersCollection, h.HandleRouter); err != nil {
+		return err
+	}
+
+	return streamCollection(ctx, c, _locationsCollection, h.HandleLocation)
+}
+
+// streamCollection requests every page of a collection using _page and _limit query parameters.
+// The Link header's next relation is followed when present, otherwise pages are requested until one isn't full
+func streamCollection[T any](ctx context.Context, c *Client, collection string, handle func(*T) error) error {
+	collectionURL, err := c.collectionURL(collection)
+	if err != nil {
+		return err
+	}
+
+	var (
+		visited  = make(map[string]struct{})
+		previous [sha256.Size]byte
+	)
+
+	for page, next := 1, pageURL(collectionURL, 1, c.options.pageLimit); next != ""; page++ {
+		// a misbehaving server linking back to an earlier page would otherwise loop forever
+		if _, ok := visited[next]; ok {
+			return fmt.Errorf("%s pagination revisits %s", collection, next)
+		}
+		visited[next] = struct{}{}
+
+		count, header, digest, err := streamPage(ctx, c, next, handle)
+		if err != nil {
+			return fmt.Errorf("%s page %d: %w", collection, page, err)
+		}
+
+		// a server honouring _limit but ignoring _page serves the first page for every page, full each time
+		if page > 1 && count > 0 && digest == previous {
+			return fmt.Errorf("%s page %d repeats page %d, the server may be ignoring _page", collection, page, page-1)
+		}
+		previous = digest
+
+		switch link, ok := nextLink(header); {
+		case ok:
+			if next, err = resolveURL(next, link); err != nil {
+				return err
+			}
+		case header.Get("Link") == "" && count >= c.options.pageLimit:
+			next = pageURL(collectionURL, page+1, c.options.pageLimit)
+		default:
+			next = ""
+		}
+	}
+
+	return nil
+}
+
+// streamPage requests a single page of a collection, returning how many items it held, the response headers and a
+// digest of the body to tell repeated pages apart
+func streamPage[T any](ctx context.Context, c *Client, pageURL string, handle func(*T) error) (int, http.Header, [sha256.Size]byte, error) {
+	var digest [sha256.Size]byte
+
+	resp, err := c.get(ctx, pageURL)
+	if err != nil {
+		return 0, nil, digest, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	hash := sha256.New()
+
+	count, err := decodeArray(json.NewDecoder(io.TeeReader(limitBody(resp.Body, c.options.maxBodySize), hash)), handle)
+	if err != nil {
+		return count, nil, digest, fmt.Errorf("unmarshal response body %w", err)
+	}
+
+	hash.Sum(digest[:0])
+
+	return count, resp.Header, digest, nil
+}
+
+// collectionURL builds the URL of a collection from the base URL, dropping the combined /db document path
+func (c *Client) collectionURL(collection string) (*url.URL, error) {
+	u, err := url.Parse(c.options.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+
+	root := strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/db")
+	u.Path = root + "/" + collection
+	u.RawQuery = ""
+
+	return u, nil
+}
+
+// pageURL sets the pagination query parameters on a collection URL
+func pageURL(collectionURL *url.URL, page, limit int) string {
+	u := *collectionURL
+
+	query := u.Query()
+	query.Set("_page", strconv.Itoa(page))
+	query.Set("_limit", strconv.Itoa(limit))
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// nextLink returns the target of the rel="next" entry in a Link header, e.g. <http://host/routers?_page=2>; rel="next"
+func nextLink(header http.Header) (string, bool) {
+	for _, value := range header.Values("Link") {
+		for _, entry := range strings.Split(value, ",") {
+			parts := strings.Split(entry, ";")
+
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+
+			for _, param := range parts[1:] {
+				key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if ok && strings.EqualFold(key, "rel") && strings.Trim(value, `"`) == "next" {
+					return target[1 : len(target)-1], true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
+
+// resolveURL resolves a possibly relative link against the URL it was returned from
+func resolveURL(base, link string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	linkURL, err := url.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("parse next link: %w", err)
+	}
+
+	return baseURL.ResolveReference(linkURL).String(), nil
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetRouterLocationData_Paginated(t *testing.T) {
+	ctx := context.Background()
+
+	routerPages := map[string]string{
+		"1": `[{"id": 1, "name": "citadel-01", "location_id": 1, "router_links": [2]}, {"id": 2, "name": "citadel-02", "location_id": 2, "router_links": [1]}]`,
+		"2": `[{"id": 3, "name": "core-07", "location_id": 2, "router_links": []}]`,
+	}
+	locationPages := map[string]string{
+		"1": `[{"id": 1, "postcode": "BE12 2ND", "name": "Birmingham Motorcycle Museum"}, {"id": 2, "postcode": "BE12 2ND", "name": "Birmingham Hippodrome"}]`,
+		"2": `[]`,
+	}
+
+	var requested []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.RequestURI())
+
+		page := r.URL.Query().Get("_page")
+		assert.Equal(t, "2", r.URL.Query().Get("_limit"))
+
+		switch r.URL.Path {
+		case "/api/routers":
+			// routers follow the Link header, typicode style
+			if page == "1" {
+				w.Header().Set("Link", `</api/routers?_page=1&_limit=2>; rel="first", </api/routers?_page=2&_limit=2>; rel="next", </api/routers?_page=2&_limit=2>; rel="last"`)
+			} else {
+				w.Header().Set("Link", `</api/routers?_page=1&_limit=2>; rel="first", </api/routers?_page=1&_limit=2>; rel="prev"`)
+			}
+			w.Write([]byte(routerPages[page]))
+		case "/api/locations":
+			// locations have no Link header so are requested until a page isn't full
+			w.Write([]byte(locationPages[page]))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := New(WithMaxRetries(0),
+		WithBaseURL(server.URL+"/api/db"),
+		WithTimeout(10*time.Second),
+		WithPageLimit(2))
+
+	got, err := c.GetRouterLocationData(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &RouterLocationData{
+		Routers: []Router{
+			{ID: 1, Name: "citadel-01", LocationID: 1, RouterLinks: []int{2}},
+			{ID: 2, Name: "citadel-02", LocationID: 2, RouterLinks: []int{1}},
+			{ID: 3, Name: "core-07", LocationID: 2, RouterLinks: []int{}},
+		},
+		Locations: []Location{
+			{ID: 1, Postcode: "BE12 2ND", Name: "Birmingham Motorcycle Museum"},
+			{ID: 2, Postcode: "BE12 2ND", Name: "Birmingham Hippodrome"},
+		},
+	}, got)
+	assert.Equal(t, []string{
+		"/api/routers?_limit=2&_page=1",
+		"/api/routers?_page=2&_limit=2",
+		"/api/locations?_limit=2&_page=1",
+		"/api/locations?_limit=2&_page=2",
+	}, requested)
+}
+
+func TestClient_GetRouterLocationData_PaginationLoop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, r.URL.RequestURI()))
+		w.Write([]byte(`[{"id": ` + strconv.Itoa(len(r.URL.Query())) + `}]`))
+	}))
+	defer server.Close()
+
+	c := New(WithMaxRetries(0),
+		WithBaseURL(server.URL),
+		WithTimeout(10*time.Second),
+		WithPageLimit(1))
+
+	_, err := c.GetRouterLocationData(context.Background())
+	assert.EqualError(t, err, "routers pagination revisits "+server.URL+"/routers?_limit=1&_page=1")
+}
+
+func TestClient_GetRouterLocationData_PaginationIgnoringPage(t *testing.T) {
+	var requests int
+
+	// the server honours _limit but serves the first page whatever _page is asked for, with no Link header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "2", r.URL.Query().Get("_limit"))
+
+		w.Write([]byte(`[{"id": 1, "name": "citadel-01", "location_id": 1, "router_links": [2]}, {"id": 2, "name": "citadel-02", "location_id": 1, "router_links": [1]}]`))
+	}))
+	defer server.Close()
+
+	c := New(WithMaxRetries(0),
+		WithBaseURL(server.URL),
+		WithTimeout(10*time.Second),
+		WithPageLimit(2))
+
+	_, err := c.GetRouterLocationData(context.Background())
+	assert.EqualError(t, err, "routers page 2 repeats page 1, the server may be ignoring _page")
+	assert.Equal(t, 2, requests)
+}
+
+func Test_nextLink(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "returns the next relation",
+			header: http.Header{"Link": {`<http://host/routers?_page=1>; rel="first", <http://host/routers?_page=3>; rel="next"`}},
+			want:   "http://host/routers?_page=3",
+			wantOK: true,
+		},
+		{
+			name:   "returns false on the last page",
+			header: http.Header{"Link": {`<http://host/routers?_page=1>; rel="first", <http://host/routers?_page=2>; rel="prev"`}},
+		},
+		{
+			name:   "returns false without a Link header",
+			header: http.Header{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := nextLink(tt.header)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
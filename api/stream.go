@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrBodyTooLarge is returned when router location data exceeds the configured max body size
+var ErrBodyTooLarge = errors.New("body exceeds max body size")
+
+// Handler receives routers and locations one at a time as they're decoded
+type Handler interface {
+	HandleRouter(router *Router) error
+	HandleLocation(location *Location) error
+}
+
+// collector is a Handler gathering every router and location into RouterLocationData
+type collector struct {
+	data RouterLocationData
+}
+
+func (c *collector) HandleRouter(router *Router) error {
+	c.data.Routers = append(c.data.Routers, *router)
+
+	return nil
+}
+
+func (c *collector) HandleLocation(location *Location) error {
+	c.data.Locations = append(c.data.Locations, *location)
+
+	return nil
+}
+
+// decodeRouterLocationData walks the tokens of a router location data document, passing each router and location to
+// the handler as it's decoded so the whole document is never held in memory. Unknown fields are skipped
+func decodeRouterLocationData(r io.Reader, h Handler) error {
+	dec := json.NewDecoder(r)
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch key, _ := token.(string); key {
+		case _routersCollection:
+			_, err = decodeArray(dec, h.HandleRouter)
+		case _locationsCollection:
+			_, err = decodeArray(dec, h.HandleLocation)
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(dec, '}')
+}
+
+// decodeArray decodes each element of a JSON array in turn, returning how many elements were handled
+func decodeArray[T any](dec *json.Decoder, handle func(*T) error) (int, error) {
+	if err := expectDelim(dec, '['); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for dec.More() {
+		var item T
+		if err := dec.Decode(&item); err != nil {
+			return count, err
+		}
+
+		if err := handle(&item); err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, expectDelim(dec, ']')
+}
+
+// expectDelim reads the next token, failing unless it's the given delimiter
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+
+		return err
+	}
+
+	if token != delim {
+		return fmt.Errorf("expected %s but found %v", delim, token)
+	}
+
+	return nil
+}
+
+// limitReader fails with ErrBodyTooLarge rather than silently truncating once more than max bytes are read
+type limitReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+// limitBody wraps a body with the max body size guard, a max of zero or less disables it
+func limitBody(r io.Reader, max int64) io.Reader {
+	if max <= 0 {
+		return r
+	}
+
+	return &limitReader{r: r, remaining: max}
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+
+	// read one byte past the limit so a body of exactly the max size is still accepted
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+
+	if l.remaining < 0 {
+		return n, ErrBodyTooLarge
+	}
+
+	return n, err
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_decodeRouterLocationData(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		maxBodySize int64
+		want        RouterLocationData
+		wantErr     error
+		wantErrMsg  string
+	}{
+		{
+			name: "passes routers and locations to the handler skipping unknown fields",
+			body: `{
+  "generated_at": "2024-03-14T23:38:46Z",
+  "routers": [{"id": 1, "name": "citadel-01", "location_id": 1, "router_links": [2], "vendor": {"name": "acme"}}],
+  "meta": [1, 2, {"a": null}],
+  "locations": [{"id": 1, "postcode": "BE12 2ND", "name": "Birmingham Motorcycle Museum"}]
+}`,
+			want: RouterLocationData{
+				Routers:   []Router{{ID: 1, Name: "citadel-01", LocationID: 1, RouterLinks: []int{2}}},
+				Locations: []Location{{ID: 1, Postcode: "BE12 2ND", Name: "Birmingham Motorcycle Museum"}},
+			},
+		},
+		{
+			name:        "accepts a body of exactly the max body size",
+			body:        `{"routers": []}`,
+			maxBodySize: int64(len(`{"routers": []}`)),
+		},
+		{
+			name:        "fails when the body exceeds the max body size",
+			body:        `{"routers": [{"id": 1}, {"id": 2}]}`,
+			maxBodySize: 16,
+			wantErr:     ErrBodyTooLarge,
+		},
+		{
+			name:       "fails when the document isn't an object",
+			body:       `[]`,
+			wantErrMsg: "expected { but found [",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got collector
+			err := decodeRouterLocationData(limitBody(strings.NewReader(tt.body), tt.maxBodySize), &got)
+
+			switch {
+			case tt.wantErr != nil:
+				assert.True(t, errors.Is(err, tt.wantErr), "got error %v", err)
+			case tt.wantErrMsg != "":
+				assert.EqualError(t, err, tt.wantErrMsg)
+			default:
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got.data)
+		})
+	}
+}
@@ -2,129 +2,355 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"time"
 
-	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
-	
+
 	"router-location-connecter/api"
+	"router-location-connecter/graph"
+	"router-location-connecter/output"
 	"router-location-connecter/storage"
+	"router-location-connecter/validate"
 )
 
 type app struct {
-	apiClient api.API
-	storage   storage.Storage
-	log       zerolog.Logger
+	apiClient  api.API
+	storage    storage.Storage
+	emitter    output.Emitter
+	log        zerolog.Logger
+	linkPolicy graph.LinkPolicy
+	// validateMode is validate.Off unless WithValidation is used
+	validateMode validate.Mode
+	reportWriter io.Writer
+	reportFormat string
+	// snapshots is nil unless WithSnapshots is used
+	snapshots *snapshotRetention
+	now       func() time.Time
+	// workers is how many chunks of routers and locations are written to storage concurrently
+	workers   int
+	errorMode ErrorMode
 }
 
-func NewApp(client api.API, redisClient storage.Storage, l zerolog.Logger) app {
-	return app{
-		apiClient: client,
-		storage:   redisClient,
-		log:       l,
+func NewApp(client api.API, redisClient storage.Storage, emitter output.Emitter, l zerolog.Logger, opts ...Option) app {
+	a := app{
+		apiClient:    client,
+		storage:      redisClient,
+		emitter:      emitter,
+		log:          l,
+		linkPolicy:   graph.RequireBoth,
+		validateMode: validate.Off,
+		reportFormat: validate.FormatText,
+		now:          time.Now,
+		workers:      1,
+		errorMode:    CollectAll,
+	}
+
+	for _, opt := range opts {
+		opt(&a)
 	}
+
+	return a
 }
 
-// Process runs the logic of coordinating the retrieval of data and processing it
-func (a *app) Process(ctx context.Context) {
-	// request api data
-	rLocData, err := a.apiClient.GetRouterLocationData(ctx)
+// Process runs the logic of coordinating the retrieval of data and processing it.
+// An error is returned when the run can't complete, routers and locations failed to store, according to the error
+// mode, a location link failed to store or the snapshot couldn't be saved. Links between locations which don't exist
+// are skipped
+func (a *app) Process(ctx context.Context) error {
+	_, err := a.process(ctx)
+
+	return err
+}
+
+// process is Process returning the router graph built from the data. When storage supports staging the run is
+// all-or-nothing, a failed run leaving the stored data as it was and outputting nothing
+func (a *app) process(ctx context.Context) (*graph.Graph, error) {
+	var (
+		routerGraph *graph.Graph
+		err         error
+	)
+
+	if stager, ok := a.storage.(storage.Stager); ok {
+		routerGraph, err = a.processStaged(ctx, stager)
+	} else {
+		routerGraph, err = a.ingest(ctx)
+	}
+
 	if err != nil {
-		log.Error().Err(err).Msg("get router location data")
+		return nil, err
+	}
 
-		return
+	if a.snapshots != nil {
+		if err := a.snapshot(routerGraph); err != nil {
+			return nil, err
+		}
+	}
+
+	return routerGraph, nil
+}
+
+// processStaged runs the ingest against a new stage, committing it when the run succeeds and discarding it otherwise
+func (a *app) processStaged(ctx context.Context, stager storage.Stager) (*graph.Graph, error) {
+	stage, err := stager.Stage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("stage storage: %w", err)
 	}
 
-	// keep track of routers already processed as we process based on links
-	processedRouters := make(map[int]struct{}, 0)
+	// the run writes to the stage in place of storage, holding back its output until the stage is committed
+	var buffer bufferedEmitter
 
-	a.SaveRouterData(rLocData.Routers)
-	a.SaveLocationData(rLocData.Locations)
+	staged := *a
+	staged.storage = stage
+	staged.emitter = &buffer
 
-	// output list of connections between locations
-	for _, router := range rLocData.Routers {
-		if _, ok := processedRouters[router.ID]; ok {
-			// already processed router entry
-			continue
+	routerGraph, err := staged.ingestStaged(ctx, a.storage)
+	if err == nil {
+		if err = stage.Commit(ctx); err != nil {
+			err = fmt.Errorf("commit staged router location data: %w", err)
 		}
+	}
 
-		for _, rLinkID := range router.RouterLinks {
+	if err != nil {
+		// the stage is discarded even when the run was cancelled
+		if discardErr := stage.Discard(context.WithoutCancel(ctx)); discardErr != nil {
+			log.Error().Err(discardErr).Msg("discard staged router location data")
+		}
 
-			if router.ID == rLinkID {
-				continue
-			}
+		return nil, err
+	}
 
-			linkedRouter, err := a.storage.GetRouter(rLinkID)
-			if err != nil {
-				if err != redis.Nil {
-					log.Error().Err(err).Msg("get router data")
-				}
-				continue
-			}
+	if err := buffer.writeTo(a.emitter); err != nil {
+		return nil, fmt.Errorf("flush output: %w", err)
+	}
+
+	return routerGraph, nil
+}
+
+// ingestStaged is ingest run against a stage, seeding it with the links in the stored data beforehand and dropping the
+// links no longer in the data afterwards
+func (a *app) ingestStaged(ctx context.Context, stored storage.Storage) (*graph.Graph, error) {
+	links, err := stored.ListRouterLocationLinks()
+	if err != nil {
+		return nil, fmt.Errorf("read stored links: %w", err)
+	}
 
-			a.processLinkedRouter(&router, linkedRouter, processedRouters)
+	for _, link := range links {
+		if err := a.storage.AddRouterLocationLink(&link); err != nil {
+			return nil, fmt.Errorf("stage link: %w", err)
 		}
 	}
-}
 
-// processLinkedRouter is a recursive function responsible for crawling through router links and calculating connections
-func (a *app) processLinkedRouter(parentRouter, linkedRouter *api.Router, processedRouters map[int]struct{}) {
-	if _, ok := processedRouters[linkedRouter.ID]; ok {
-		// already processed linked router entry and since bidirectional its ok to skip
-		return
+	routerGraph, err := a.ingest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.pruneLinks(routerGraph); err != nil {
+		return nil, fmt.Errorf("prune staged links: %w", err)
 	}
 
-	for _, link := range linkedRouter.RouterLinks {
-		//  check if link is bidirectional and follow links within the linked routers link
-		// 2 routers connected at same location
-		if parentRouter.LocationID == linkedRouter.LocationID {
-			continue
+	return routerGraph, nil
+}
+
+// pruneLinks deletes the links in storage which aren't in the router graph. Links are matched on their unique ID so the
+// link of a renamed location is replaced
+func (a *app) pruneLinks(routerGraph *graph.Graph) error {
+	current := make(map[string]struct{})
+	for _, link := range routerGraph.LocationLinks() {
+		src, srcOK := routerGraph.Location(link.SourceID)
+		dest, destOK := routerGraph.Location(link.DestinationID)
+		if srcOK && destOK {
+			current[sortLocations(src.Name, dest.Name)] = struct{}{}
 		}
+	}
 
-		if link == parentRouter.ID {
-			processedRouters[parentRouter.ID] = struct{}{}
-			if err := a.CalculateLink(parentRouter.LocationID, linkedRouter.LocationID); err != nil {
-				if err != redis.Nil {
-					log.Error().Err(err).Msg("calculate link")
-				}
-			}
-		} else {
-			// get routers for other the linked routers within the linked router
-			addLinkedRouter, err := a.storage.GetRouter(link)
-			if err != nil {
-				if err != redis.Nil {
-					log.Error().Err(err).Msg("get router data")
-				}
-				continue
+	links, err := a.storage.ListRouterLocationLinks()
+	if err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		if _, ok := current[link.UniqueID]; !ok {
+			if err := a.storage.DeleteRouterLocationLink(link.UniqueID); err != nil {
+				return err
 			}
+		}
+	}
 
-			a.processLinkedRouter(linkedRouter, addLinkedRouter, processedRouters)
+	return nil
+}
+
+// ingest streams the router location data into storage, builds the router graph and outputs the location links
+func (a *app) ingest(ctx context.Context) (*graph.Graph, error) {
+	builder := graph.NewBuilder(graph.WithLinkPolicy(a.linkPolicy))
+	pool := newWorkerPool(ctx, a.workers, a.errorMode)
+	ingest := newIngester(a, builder, pool)
+
+	// request api data, streaming each router and location into the graph as it's decoded while the pool stores them
+	streamErr := a.apiClient.StreamRouterLocationData(ctx, ingest)
+	if streamErr == nil {
+		streamErr = ingest.flush()
+	}
+
+	// a failed write stops the stream under FirstError, so it's reported ahead of the stream's error
+	if err := pool.wait(); err != nil {
+		return nil, fmt.Errorf("store router location data: %w", err)
+	}
+
+	if streamErr != nil {
+		return nil, fmt.Errorf("get router location data: %w", streamErr)
+	}
+
+	if ingest.validator != nil {
+		if err := a.reportValidation(ingest.validator.Report()); err != nil {
+			return nil, err
+		}
+	}
+
+	// output list of connections between locations, the graph orders them so output doesn't depend on the order of the api data
+	routerGraph, err := buildGraph(builder)
+	if err != nil {
+		return nil, err
+	}
 
+	if graphEmitter, ok := a.emitter.(output.GraphEmitter); ok {
+		if err := graphEmitter.EmitGraph(routerGraph); err != nil {
+			return nil, fmt.Errorf("emit router graph: %w", err)
 		}
 	}
+
+	// links between locations which don't exist are skipped, any other failure fails the run so a staged run is
+	// discarded rather than committed without the link
+	for _, link := range routerGraph.LocationLinks() {
+		if err := a.CalculateLink(link.SourceID, link.DestinationID, routerPairs(routerGraph, link)); err != nil {
+			if !errors.Is(err, storage.ErrNotFound) {
+				return nil, fmt.Errorf("calculate link: %w", err)
+			}
+		}
+	}
+
+	if err := a.emitter.Flush(); err != nil {
+		return nil, fmt.Errorf("flush output: %w", err)
+	}
+
+	return routerGraph, nil
 }
 
-// SaveRouterData makes a call to storage to save router data
-func (a *app) SaveRouterData(routers []api.Router) {
-	for _, router := range routers {
-		if err := a.storage.AddRouter(&router); err != nil {
-			log.Error().Err(err).Msg("store router data")
+// buildGraph builds the router graph, logging each one-sided router link when the link policy fails the build on them
+func buildGraph(builder *graph.Builder) (*graph.Graph, error) {
+	routerGraph, err := builder.Build()
+	if err != nil {
+		var oneSidedErr *graph.OneSidedLinksError
+		if errors.As(err, &oneSidedErr) {
+			for _, link := range oneSidedErr.Links {
+				log.Error().
+					Int("router_id", link.RouterID).
+					Str("router_name", link.RouterName).
+					Int("linked_router_id", link.LinkedRouterID).
+					Str("linked_router_name", link.LinkedRouterName).
+					Msg("one-sided router link")
+			}
 		}
+
+		return nil, fmt.Errorf("build router graph: %w", err)
+	}
+
+	return routerGraph, nil
+}
+
+// routerPairs looks up the router names for the router links producing a location link
+func routerPairs(routerGraph *graph.Graph, link graph.LocationLink) []output.RouterPair {
+	pairs := make([]output.RouterPair, 0, len(link.Routers))
+
+	for _, pair := range link.Routers {
+		src, _ := routerGraph.Router(pair.A)
+		dest, _ := routerGraph.Router(pair.B)
+
+		pairs = append(pairs, output.RouterPair{
+			SourceID:        src.ID,
+			SourceName:      src.Name,
+			DestinationID:   dest.ID,
+			DestinationName: dest.Name,
+		})
 	}
+
+	return pairs
 }
 
-// SaveLocationData makes a call to storage to save location data
-func (a *app) SaveLocationData(locations []api.Location) {
-	for _, location := range locations {
-		if err := a.storage.AddLocation(&location); err != nil {
-			log.Error().Err(err).Msg("store location data")
+// SaveRouterData makes a call to storage to save router data as a batch, logging and returning any failure
+func (a *app) SaveRouterData(routers []api.Router) error {
+	if len(routers) == 0 {
+		return nil
+	}
+
+	if err := a.storage.AddRouters(routers); err != nil {
+		logBatchError(err, "store router data")
+
+		return err
+	}
+
+	return nil
+}
+
+// SaveLocationData makes a call to storage to save location data as a batch, logging and returning any failure
+func (a *app) SaveLocationData(locations []api.Location) error {
+	if len(locations) == 0 {
+		return nil
+	}
+
+	if err := a.storage.AddLocations(locations); err != nil {
+		logBatchError(err, "store location data")
+
+		return err
+	}
+
+	return nil
+}
+
+// logBatchError logs each record of a batch write which failed, or the error when the batch failed as a whole
+func logBatchError(err error, msg string) {
+	var batchErr *storage.BatchError
+	if !errors.As(err, &batchErr) {
+		log.Error().Err(err).Msg(msg)
+		return
+	}
+
+	for _, item := range batchErr.Items {
+		log.Error().Err(item.Err).Str("key", item.Key).Msg(msg)
+	}
+}
+
+// reportValidation logs and writes the data-quality report when it has issues, under validate.Fail an error is returned
+// when the report has errors
+func (a *app) reportValidation(report *validate.Report) error {
+	if len(report.Issues) == 0 {
+		return nil
+	}
+
+	log.Warn().
+		Int("errors", report.Errors()).
+		Int("warnings", report.Warnings()).
+		Msg("router location data has data-quality issues")
+
+	if a.reportWriter != nil {
+		if err := report.Write(a.reportWriter, a.reportFormat); err != nil {
+			return fmt.Errorf("write validation report: %w", err)
 		}
 	}
+
+	if a.validateMode == validate.Fail && report.Errors() > 0 {
+		return fmt.Errorf("validate router location data: %w", &validate.Error{Report: report})
+	}
+
+	return nil
 }
 
-// CalculateLink calculates links between router locations and prints to stdout
-func (a *app) CalculateLink(srcLocationID, destLocationID int) error {
+// CalculateLink calculates links between router locations and writes new links to the emitter
+func (a *app) CalculateLink(srcLocationID, destLocationID int, routers []output.RouterPair) error {
 	// link is bi-directional, get link details
 	srcLocation, err := a.storage.GetLocation(srcLocationID)
 	if err != nil {
@@ -140,7 +366,7 @@ func (a *app) CalculateLink(srcLocationID, destLocationID int) error {
 	// we generate a unique alphabetically sorted ID
 	linkUniqueID := sortLocations(srcLocation.Name, destLocation.Name)
 	exists, err := a.storage.GetRouterLocationLink(linkUniqueID)
-	if err != redis.Nil {
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
 		return err
 	}
 
@@ -148,14 +374,31 @@ func (a *app) CalculateLink(srcLocationID, destLocationID int) error {
 		return nil
 	}
 
-	fmt.Printf("[%s] <-> [%s]\n", srcLocation.Name, destLocation.Name)
-	// store locationLink
-	a.storage.AddRouterLocationLink(&api.RouterLocationLink{
-		UniqueID:   linkUniqueID,
-		Connection: fmt.Sprintf("[%s] <-> [%s]", srcLocation.Name, destLocation.Name),
+	// store locationLink, a conflict means it has been stored since we checked so was already printed
+	link := locationLink(*srcLocation, *destLocation)
+	if err := a.storage.AddRouterLocationLink(&link); err != nil {
+		if errors.Is(err, storage.ErrConflict) {
+			return nil
+		}
+
+		return err
+	}
+
+	return a.emitter.Emit(output.Link{
+		Source:      *srcLocation,
+		Destination: *destLocation,
+		Routers:     routers,
 	})
+}
 
-	return nil
+// locationLink is the stored link between two locations, identified by their alphabetically sorted names
+func locationLink(src, dest api.Location) api.RouterLocationLink {
+	return api.RouterLocationLink{
+		UniqueID:      sortLocations(src.Name, dest.Name),
+		Connection:    fmt.Sprintf("[%s] <-> [%s]", src.Name, dest.Name),
+		SourceID:      src.ID,
+		DestinationID: dest.ID,
+	}
 }
 
 // Function to sort two locations alphabetically and concatenates them
@@ -1,17 +1,21 @@
 package app
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/golang/mock/gomock"
-	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"router-location-connecter/api"
 	mock_api "router-location-connecter/api/mocks"
+	"router-location-connecter/graph"
+	"router-location-connecter/output"
 	"router-location-connecter/storage"
 	mock_storage "router-location-connecter/storage/mocks"
+	"router-location-connecter/validate"
 	"testing"
 )
 
@@ -66,7 +70,8 @@ func Test_app_CalculateLink(t *testing.T) {
 		storageMockOutcomes func(storageMock *mock_storage.MockStorage)
 		srcLocationID       int
 		destLocationID      int
-		wantErr             string
+		wantErr             error
+		wantOutput          string
 	}{
 		{
 			name: "calculates location link between src and destination routers",
@@ -91,17 +96,20 @@ func Test_app_CalculateLink(t *testing.T) {
 				storageMock.EXPECT().
 					GetRouterLocationLink(sortLocations("Winterbourne House", "Birmingham Hippodrome")).
 					Times(1).
-					Return(nil, redis.Nil)
+					Return(nil, storage.ErrNotFound)
 				storageMock.EXPECT().
 					AddRouterLocationLink(&api.RouterLocationLink{
-						UniqueID:   sortLocations("Winterbourne House", "Birmingham Hippodrome"),
-						Connection: fmt.Sprintf("[%s] <-> [%s]", "Winterbourne House", "Birmingham Hippodrome"),
+						UniqueID:      sortLocations("Winterbourne House", "Birmingham Hippodrome"),
+						Connection:    fmt.Sprintf("[%s] <-> [%s]", "Winterbourne House", "Birmingham Hippodrome"),
+						SourceID:      1,
+						DestinationID: 2,
 					}).Times(1).
 					Return(nil)
 			},
 			srcLocationID:  1,
 			destLocationID: 2,
-			wantErr:        "",
+			wantErr:        nil,
+			wantOutput:     "[Winterbourne House] <-> [Birmingham Hippodrome]\n",
 		},
 		{
 			name: "calculates location link between src and destination routers, but doesnt save it when src and destination from the previous example are switched",
@@ -133,7 +141,7 @@ func Test_app_CalculateLink(t *testing.T) {
 			},
 			srcLocationID:  2,
 			destLocationID: 1,
-			wantErr:        "",
+			wantErr:        nil,
 		},
 		{
 			name: "when src and destination are the same",
@@ -150,29 +158,97 @@ func Test_app_CalculateLink(t *testing.T) {
 				storageMock.EXPECT().
 					GetRouterLocationLink(sortLocations("Winterbourne House", "Winterbourne House")).
 					Times(1).
-					Return(nil, redis.Nil)
+					Return(nil, storage.ErrNotFound)
 				storageMock.EXPECT().
 					AddRouterLocationLink(&api.RouterLocationLink{
-						UniqueID:   sortLocations("Winterbourne House", "Winterbourne House"),
-						Connection: fmt.Sprintf("[%s] <-> [%s]", "Winterbourne House", "Winterbourne House"),
+						UniqueID:      sortLocations("Winterbourne House", "Winterbourne House"),
+						Connection:    fmt.Sprintf("[%s] <-> [%s]", "Winterbourne House", "Winterbourne House"),
+						SourceID:      1,
+						DestinationID: 1,
 					}).Times(1).
 					Return(nil)
 			},
 			srcLocationID:  1,
 			destLocationID: 1,
-			wantErr:        "",
+			wantErr:        nil,
+			wantOutput:     "[Winterbourne House] <-> [Winterbourne House]\n",
+		},
+		{
+			name: "returns not found when the destination location is missing",
+			log:  log,
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().
+					GetLocation(1).
+					Times(1).
+					Return(&api.Location{
+						ID:       1,
+						Postcode: "BE13 1EQ",
+						Name:     "Winterbourne House",
+					}, nil)
+				storageMock.EXPECT().
+					GetLocation(9).
+					Times(1).
+					Return(nil, fmt.Errorf("key location_id_9: %w", storage.ErrNotFound))
+			},
+			srcLocationID:  1,
+			destLocationID: 9,
+			wantErr:        storage.ErrNotFound,
+		},
+		{
+			name: "does not return an error when the link is stored concurrently",
+			log:  log,
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().
+					GetLocation(1).
+					Times(1).
+					Return(&api.Location{
+						ID:       1,
+						Postcode: "BE13 1EQ",
+						Name:     "Winterbourne House",
+					}, nil)
+				storageMock.EXPECT().
+					GetLocation(2).
+					Times(1).
+					Return(&api.Location{
+						ID:       2,
+						Postcode: "BE12 2ND",
+						Name:     "Birmingham Hippodrome",
+					}, nil)
+				storageMock.EXPECT().
+					GetRouterLocationLink(sortLocations("Winterbourne House", "Birmingham Hippodrome")).
+					Times(1).
+					Return(nil, storage.ErrNotFound)
+				storageMock.EXPECT().
+					AddRouterLocationLink(&api.RouterLocationLink{
+						UniqueID:      sortLocations("Winterbourne House", "Birmingham Hippodrome"),
+						Connection:    fmt.Sprintf("[%s] <-> [%s]", "Winterbourne House", "Birmingham Hippodrome"),
+						SourceID:      1,
+						DestinationID: 2,
+					}).Times(1).
+					Return(storage.ErrConflict)
+			},
+			srcLocationID:  1,
+			destLocationID: 2,
+			wantErr:        nil,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.storageMockOutcomes(storageMock)
+
+			var out bytes.Buffer
 			a := &app{
 				storage: storageMock,
+				emitter: output.NewText(&out),
 				log:     tt.log,
 			}
-			if err := a.CalculateLink(tt.srcLocationID, tt.destLocationID); err != nil {
-				assert.Equal(t, err, tt.wantErr)
+			err := a.CalculateLink(tt.srcLocationID, tt.destLocationID, nil)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
 			}
+			assert.Equal(t, tt.wantOutput, out.String())
 		})
 	}
 }
@@ -197,15 +273,20 @@ func Test_app_Process(t *testing.T) {
 		apiMockOutcomes     func(apiMock *mock_api.MockAPI)
 		storageMockOutcomes func(storageMock *mock_storage.MockStorage)
 		log                 zerolog.Logger
+		linkPolicy          graph.LinkPolicy
+		validateMode        validate.Mode
+		wantErr             string
+		wantOutput          string
+		wantReport          string
 	}{
 		{
 			name: "process the API data, store router and location data and print the linked router locations",
 			api:  apiMock,
 			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
 				apiMock.EXPECT().
-					GetRouterLocationData(ctx).
+					StreamRouterLocationData(ctx, gomock.Any()).
 					Times(1).
-					Return(&api.RouterLocationData{
+					DoAndReturn(streamData(&api.RouterLocationData{
 						Routers: []api.Router{
 							{
 								ID:          1,
@@ -249,24 +330,26 @@ func Test_app_Process(t *testing.T) {
 								Name:     "Location C",
 							},
 						},
-					}, nil)
+					}))
 			},
 			storage: storageMock,
 			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
-				storageMock.EXPECT().AddLocation(&api.Location{
-					ID:       1,
-					Postcode: "A",
-					Name:     "Location A",
-				}).Times(1).Return(nil)
-				storageMock.EXPECT().AddLocation(&api.Location{
-					ID:       2,
-					Postcode: "B",
-					Name:     "Location B",
-				}).Times(1).Return(nil)
-				storageMock.EXPECT().AddLocation(&api.Location{
-					ID:       3,
-					Postcode: "C",
-					Name:     "Location C",
+				storageMock.EXPECT().AddLocations([]api.Location{
+					{
+						ID:       1,
+						Postcode: "A",
+						Name:     "Location A",
+					},
+					{
+						ID:       2,
+						Postcode: "B",
+						Name:     "Location B",
+					},
+					{
+						ID:       3,
+						Postcode: "C",
+						Name:     "Location C",
+					},
 				}).Times(1).Return(nil)
 				storageMock.EXPECT().
 					GetLocation(1).
@@ -292,223 +375,187 @@ func Test_app_Process(t *testing.T) {
 						Postcode: "C",
 						Name:     "Location C",
 					}, nil)
-				storageMock.EXPECT().AddRouter(&api.Router{
-					ID:          1,
-					Name:        "Router A",
-					LocationID:  1,
-					RouterLinks: []int{2},
-				}).Times(1).Return(nil)
-				storageMock.EXPECT().AddRouter(&api.Router{
-					ID:          2,
-					Name:        "Router B",
-					LocationID:  2,
-					RouterLinks: []int{1, 3},
+				storageMock.EXPECT().AddRouters([]api.Router{
+					{
+						ID:          1,
+						Name:        "Router A",
+						LocationID:  1,
+						RouterLinks: []int{2},
+					},
+					{
+						ID:          2,
+						Name:        "Router B",
+						LocationID:  2,
+						RouterLinks: []int{1, 3},
+					},
+					{
+						ID:          3,
+						Name:        "Router C",
+						LocationID:  3,
+						RouterLinks: []int{2},
+					},
+					{
+						ID:          4,
+						Name:        "Router D",
+						LocationID:  1,
+						RouterLinks: []int{1}, // at location 1, linked to 1
+					},
 				}).Times(1).Return(nil)
-				storageMock.EXPECT().AddRouter(&api.Router{
-					ID:          3,
-					Name:        "Router C",
-					LocationID:  3,
-					RouterLinks: []int{2},
-				}).Times(1).Return(nil)
-				storageMock.EXPECT().AddRouter(&api.Router{
-					ID:          4,
-					Name:        "Router D",
-					LocationID:  1,
-					RouterLinks: []int{1}, // at location 1, linked to 1
-				}).Times(1).Return(nil)
-				storageMock.EXPECT().GetRouter(2).Times(2).Return(&api.Router{
-					ID:          2,
-					Name:        "Router B",
-					LocationID:  2,
-					RouterLinks: []int{1, 3},
-				}, nil)
-				storageMock.EXPECT().GetRouter(3).Times(1).Return(&api.Router{
-					ID:          3,
-					Name:        "Router C",
-					LocationID:  3,
-					RouterLinks: []int{2},
-				}, nil)
-				storageMock.EXPECT().GetRouter(1).Times(1).Return(&api.Router{
-					ID:          1,
-					Name:        "Router A",
-					LocationID:  1,
-					RouterLinks: []int{1},
-				}, nil)
 				storageMock.EXPECT().
 					GetRouterLocationLink("Location A:Location B").
 					Times(1).
-					Return(nil, redis.Nil)
+					Return(nil, storage.ErrNotFound)
 				storageMock.EXPECT().AddRouterLocationLink(&api.RouterLocationLink{
-					UniqueID:   "Location A:Location B",
-					Connection: fmt.Sprintf("[%s] <-> [%s]", "Location A", "Location B"),
+					UniqueID:      "Location A:Location B",
+					Connection:    fmt.Sprintf("[%s] <-> [%s]", "Location A", "Location B"),
+					SourceID:      1,
+					DestinationID: 2,
 				}).Times(1).
 					Return(nil)
 				storageMock.EXPECT().
 					GetRouterLocationLink("Location B:Location C").
 					Times(1).
-					Return(nil, redis.Nil)
+					Return(nil, storage.ErrNotFound)
 				storageMock.EXPECT().AddRouterLocationLink(&api.RouterLocationLink{
-					UniqueID:   sortLocations("Location B", "Location C"),
-					Connection: fmt.Sprintf("[%s] <-> [%s]", "Location B", "Location C"),
+					UniqueID:      sortLocations("Location B", "Location C"),
+					Connection:    fmt.Sprintf("[%s] <-> [%s]", "Location B", "Location C"),
+					SourceID:      2,
+					DestinationID: 3,
 				}).Times(1).
 					Return(nil)
 			},
-			log: log,
+			log:        log,
+			linkPolicy: graph.RequireBoth,
+			wantOutput: "[Location A] <-> [Location B]\n[Location B] <-> [Location C]\n",
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tt.storageMockOutcomes(storageMock)
-			tt.apiMockOutcomes(apiMock)
-
-			a := &app{
-				apiClient: tt.api,
-				storage:   tt.storage,
-				log:       tt.log,
-			}
-
-			a.Process(ctx)
-		})
-	}
-}
-
-func Test_app_processLinkedRouter(t *testing.T) {
-	log := zerolog.New(os.Stdout).With().
-		Timestamp().
-		Logger()
-
-	mockController := gomock.NewController(t)
-	storageMock := mock_storage.NewMockStorage(mockController)
-	defer mockController.Finish()
-
-	tests := []struct {
-		name                string
-		parentRouter        *api.Router
-		linkedRouter        *api.Router
-		storage             storage.Storage
-		storageMockOutcomes func(storageMock *mock_storage.MockStorage)
-		log                 zerolog.Logger
-		processedRouters    map[int]struct{}
-	}{
 		{
-			name: "processes linked router connections as well as all other links in the linked router",
-			parentRouter: &api.Router{
-				ID:          2,
-				Name:        "Router B",
-				LocationID:  2,
-				RouterLinks: []int{1, 3},
-			},
-			linkedRouter: &api.Router{
-				ID:          3,
-				Name:        "Router C",
-				LocationID:  3,
-				RouterLinks: []int{2},
-			},
-			storage: storageMock,
-			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
-				storageMock.EXPECT().
-					GetLocation(2).
-					Times(1).
-					Return(&api.Location{
-						ID:       2,
-						Postcode: "B",
-						Name:     "Location B",
-					}, nil)
-				storageMock.EXPECT().
-					GetLocation(3).
-					Times(1).
-					Return(&api.Location{
-						ID:       3,
-						Postcode: "C",
-						Name:     "Location C",
-					}, nil)
-				storageMock.EXPECT().
-					GetRouterLocationLink("Location B:Location C").
+			name: "returns an error when the API data can't be retrieved",
+			api:  apiMock,
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().
+					StreamRouterLocationData(ctx, gomock.Any()).
 					Times(1).
-					Return(nil, redis.Nil)
-				storageMock.EXPECT().AddRouterLocationLink(&api.RouterLocationLink{
-					UniqueID:   sortLocations("Location B", "Location C"),
-					Connection: fmt.Sprintf("[%s] <-> [%s]", "Location B", "Location C"),
-				}).Times(1).
-					Return(nil)
+					Return(errors.New("unexpected response status code: 500"))
 			},
-			log:              log,
-			processedRouters: map[int]struct{}{},
+			storage:             storageMock,
+			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {},
+			log:                 log,
+			linkPolicy:          graph.RequireBoth,
+			wantErr:             "get router location data: unexpected response status code: 500",
 		},
 		{
-			name: "processes linked router connections when parent already exists in processed routers",
-			parentRouter: &api.Router{
-				ID:          2,
-				Name:        "Router B",
-				LocationID:  2,
-				RouterLinks: []int{1, 3},
-			},
-			linkedRouter: &api.Router{
-				ID:          3,
-				Name:        "Router C",
-				LocationID:  3,
-				RouterLinks: []int{2},
+			name: "fails under the strict link policy when a router link is one-sided",
+			api:  apiMock,
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().
+					StreamRouterLocationData(ctx, gomock.Any()).
+					Times(1).
+					DoAndReturn(streamData(&api.RouterLocationData{
+						Routers: []api.Router{
+							{
+								ID:          1,
+								Name:        "Router A",
+								LocationID:  1,
+								RouterLinks: []int{2},
+							},
+							{
+								ID:          2,
+								Name:        "Router B",
+								LocationID:  2,
+								RouterLinks: []int{},
+							},
+						},
+					}))
 			},
 			storage: storageMock,
 			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
-				storageMock.EXPECT().
-					GetLocation(2).
-					Times(1).
-					Return(&api.Location{
-						ID:       2,
-						Postcode: "B",
-						Name:     "Location B",
-					}, nil)
-				storageMock.EXPECT().
-					GetLocation(3).
-					Times(1).
-					Return(&api.Location{
-						ID:       3,
-						Postcode: "C",
-						Name:     "Location C",
-					}, nil)
-				storageMock.EXPECT().
-					GetRouterLocationLink("Location B:Location C").
-					Times(1).
-					Return(nil, redis.Nil)
-				storageMock.EXPECT().AddRouterLocationLink(&api.RouterLocationLink{
-					UniqueID:   sortLocations("Location B", "Location C"),
-					Connection: fmt.Sprintf("[%s] <-> [%s]", "Location B", "Location C"),
-				}).Times(1).
-					Return(nil)
+				storageMock.EXPECT().AddRouters(gomock.Len(2)).Times(1).Return(nil)
 			},
-			log:              log,
-			processedRouters: map[int]struct{}{2: {}},
+			log:        log,
+			linkPolicy: graph.Strict,
+			wantErr:    "build router graph: 1 one-sided router links: router 1 (Router A) -> router 2 (Router B)",
 		},
 		{
-			name: "processes linked router connections when linked router already exists in processed routers",
-			parentRouter: &api.Router{
-				ID:          2,
-				Name:        "Router B",
-				LocationID:  2,
-				RouterLinks: []int{1, 3},
-			},
-			linkedRouter: &api.Router{
-				ID:          3,
-				Name:        "Router C",
-				LocationID:  3,
-				RouterLinks: []int{2},
+			name: "fails before outputting links when validation finds errors",
+			api:  apiMock,
+			apiMockOutcomes: func(apiMock *mock_api.MockAPI) {
+				apiMock.EXPECT().
+					StreamRouterLocationData(ctx, gomock.Any()).
+					Times(1).
+					DoAndReturn(streamData(&api.RouterLocationData{
+						Routers: []api.Router{
+							{
+								ID:          1,
+								Name:        "Router A",
+								LocationID:  1,
+								RouterLinks: []int{2},
+							},
+						},
+						Locations: []api.Location{
+							{
+								ID:       1,
+								Postcode: "BE12 2ND",
+								Name:     "Location A",
+							},
+						},
+					}))
 			},
 			storage: storageMock,
 			storageMockOutcomes: func(storageMock *mock_storage.MockStorage) {
+				storageMock.EXPECT().AddRouters(gomock.Len(1)).Times(1).Return(nil)
+				storageMock.EXPECT().AddLocations(gomock.Len(1)).Times(1).Return(nil)
 			},
-			log:              log,
-			processedRouters: map[int]struct{}{3: {}},
+			log:          log,
+			linkPolicy:   graph.RequireBoth,
+			validateMode: validate.Fail,
+			wantErr:      "validate router location data: router location data failed validation: 1 error, 0 warnings",
+			wantReport:   "error  unknown-router-link  router 1 links to unknown router 2\n1 error, 0 warnings\n",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.storageMockOutcomes(storageMock)
+			tt.apiMockOutcomes(apiMock)
+
+			var out, report bytes.Buffer
 			a := &app{
-				storage: tt.storage,
-				log:     tt.log,
+				apiClient:    tt.api,
+				storage:      tt.storage,
+				emitter:      output.NewText(&out),
+				log:          tt.log,
+				linkPolicy:   tt.linkPolicy,
+				validateMode: tt.validateMode,
+				reportWriter: &report,
+				reportFormat: validate.FormatText,
+			}
+
+			err := a.Process(ctx)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
 			}
-			a.processLinkedRouter(tt.parentRouter, tt.linkedRouter, tt.processedRouters)
+			assert.Equal(t, tt.wantOutput, out.String())
+			assert.Equal(t, tt.wantReport, report.String())
 		})
 	}
 }
+
+// streamData returns a mock action passing the routers and locations to the handler as the api client would
+func streamData(data *api.RouterLocationData) func(ctx context.Context, h api.Handler) error {
+	return func(ctx context.Context, h api.Handler) error {
+		for i := range data.Routers {
+			if err := h.HandleRouter(&data.Routers[i]); err != nil {
+				return err
+			}
+		}
+
+		for i := range data.Locations {
+			if err := h.HandleLocation(&data.Locations[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
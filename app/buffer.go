@@ -0,0 +1,50 @@
+package app
+
+import (
+	"router-location-connecter/graph"
+	"router-location-connecter/output"
+)
+
+// bufferedEmitter holds the output of a staged run until the stage is committed, so links which were never stored
+// aren't output
+type bufferedEmitter struct {
+	graph *graph.Graph
+	links []output.Link
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ output.GraphEmitter = (*bufferedEmitter)(nil)
+
+func (b *bufferedEmitter) Emit(link output.Link) error {
+	b.links = append(b.links, link)
+
+	return nil
+}
+
+func (b *bufferedEmitter) EmitGraph(g *graph.Graph) error {
+	b.graph = g
+
+	return nil
+}
+
+// Flush is a no-op, the buffered output is written by writeTo once the stage is committed
+func (b *bufferedEmitter) Flush() error {
+	return nil
+}
+
+// writeTo writes the buffered router graph and links to the emitter and flushes it
+func (b *bufferedEmitter) writeTo(e output.Emitter) error {
+	if graphEmitter, ok := e.(output.GraphEmitter); ok && b.graph != nil {
+		if err := graphEmitter.EmitGraph(b.graph); err != nil {
+			return err
+		}
+	}
+
+	for _, link := range b.links {
+		if err := e.Emit(link); err != nil {
+			return err
+		}
+	}
+
+	return e.Flush()
+}
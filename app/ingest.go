@@ -0,0 +1,117 @@
+package app
+
+import (
+	"router-location-connecter/api"
+	"router-location-connecter/graph"
+	"router-location-connecter/validate"
+)
+
+// _ingestChunkSize is how many routers or locations are buffered before being saved to storage
+const _ingestChunkSize = 500
+
+// ingester is an api.Handler saving streamed routers and locations to storage in chunks, while adding them to the graph
+// builder and validator, so the full data set is never held twice in memory. Chunks are written by the worker pool
+// concurrently with the stream
+type ingester struct {
+	app     *app
+	builder *graph.Builder
+	pool    *workerPool
+	// validator is nil when validation is off
+	validator *validate.Validator
+	routers   []api.Router
+	locations []api.Location
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ api.Handler = (*ingester)(nil)
+
+func newIngester(a *app, builder *graph.Builder, pool *workerPool) *ingester {
+	i := &ingester{
+		app:       a,
+		builder:   builder,
+		pool:      pool,
+		routers:   make([]api.Router, 0, _ingestChunkSize),
+		locations: make([]api.Location, 0, _ingestChunkSize),
+		validator: a.newValidator(),
+	}
+
+	return i
+}
+
+// newValidator returns the validator checking the data against the validate mode, nil when validation is off
+func (a *app) newValidator() *validate.Validator {
+	switch a.validateMode {
+	case validate.Warn, validate.Fail:
+		return validate.New()
+	}
+
+	return nil
+}
+
+func (i *ingester) HandleRouter(router *api.Router) error {
+	i.builder.AddRouter(*router)
+
+	if i.validator != nil {
+		i.validator.AddRouter(*router)
+	}
+
+	i.routers = append(i.routers, *router)
+	if len(i.routers) >= _ingestChunkSize {
+		return i.flushRouters()
+	}
+
+	return nil
+}
+
+func (i *ingester) HandleLocation(location *api.Location) error {
+	i.builder.AddLocation(*location)
+
+	if i.validator != nil {
+		i.validator.AddLocation(*location)
+	}
+
+	i.locations = append(i.locations, *location)
+	if len(i.locations) >= _ingestChunkSize {
+		return i.flushLocations()
+	}
+
+	return nil
+}
+
+// flush saves any routers and locations still buffered
+func (i *ingester) flush() error {
+	if err := i.flushRouters(); err != nil {
+		return err
+	}
+
+	return i.flushLocations()
+}
+
+// flushRouters hands the buffered routers to the worker pool, a new buffer is started as the pool still reads the old one
+func (i *ingester) flushRouters() error {
+	if len(i.routers) == 0 {
+		return nil
+	}
+
+	routers := i.routers
+	i.routers = make([]api.Router, 0, _ingestChunkSize)
+
+	return i.pool.submit(func() error {
+		return i.app.SaveRouterData(routers)
+	})
+}
+
+// flushLocations hands the buffered locations to the worker pool, a new buffer is started as the pool still reads the
+// old one
+func (i *ingester) flushLocations() error {
+	if len(i.locations) == 0 {
+		return nil
+	}
+
+	locations := i.locations
+	i.locations = make([]api.Location, 0, _ingestChunkSize)
+
+	return i.pool.submit(func() error {
+		return i.app.SaveLocationData(locations)
+	})
+}
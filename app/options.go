@@ -0,0 +1,46 @@
+package app
+
+import (
+	"io"
+	"time"
+
+	"router-location-connecter/graph"
+	"router-location-connecter/validate"
+)
+
+// Option specifies a builder function for configuring the app
+type Option func(*app)
+
+// WithLinkPolicy sets how router links declared by only one router are handled
+func WithLinkPolicy(policy graph.LinkPolicy) Option {
+	return func(a *app) {
+		a.linkPolicy = policy
+	}
+}
+
+// WithValidation checks the data quality of router location data as it's ingested, writing the report to w in the given
+// format when there are issues. Under validate.Fail a report with errors stops the run before any links are output
+func WithValidation(mode validate.Mode, w io.Writer, format string) Option {
+	return func(a *app) {
+		a.validateMode = mode
+		a.reportWriter = w
+		a.reportFormat = format
+	}
+}
+
+// WithSnapshots stores an immutable snapshot of the router location data after every ingest, then prunes the snapshots
+// beyond the newest keep or older than maxAge, zero disabling either limit
+func WithSnapshots(keep int, maxAge time.Duration) Option {
+	return func(a *app) {
+		a.snapshots = &snapshotRetention{keep: keep, maxAge: maxAge}
+	}
+}
+
+// WithWorkers writes chunks of routers and locations to storage on a pool of workers while the data is still streaming.
+// The error mode decides whether the first failed write cancels the rest or every write runs before the ingest fails
+func WithWorkers(workers int, mode ErrorMode) Option {
+	return func(a *app) {
+		a.workers = workers
+		a.errorMode = mode
+	}
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrorMode decides how the worker pool handles a failed write
+type ErrorMode string
+
+const (
+	// FirstError cancels the writes still to run on the first failure and fails the ingest with it
+	FirstError ErrorMode = "first"
+	// CollectAll runs every write and fails the ingest with all of the failures
+	CollectAll ErrorMode = "all"
+)
+
+// ParseErrorMode converts a flag value to an ErrorMode
+func ParseErrorMode(mode string) (ErrorMode, error) {
+	switch m := ErrorMode(mode); m {
+	case FirstError, CollectAll:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown error mode %q, must be one of %s|%s", mode, FirstError, CollectAll)
+	}
+}
+
+// workerPool runs jobs on a bounded number of goroutines. Submitting blocks while every worker is busy so a producer,
+// e.g. the api stream, can't get ahead of storage
+type workerPool struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	mode   ErrorMode
+	jobs   chan func() error
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// newWorkerPool starts the workers, at least one is always started
+func newWorkerPool(ctx context.Context, workers int, mode ErrorMode) *workerPool {
+	poolCtx, cancel := context.WithCancel(ctx)
+
+	p := &workerPool{
+		parent: ctx,
+		ctx:    poolCtx,
+		cancel: cancel,
+		mode:   mode,
+		jobs:   make(chan func() error),
+	}
+
+	for range max(workers, 1) {
+		p.wg.Add(1)
+		go p.work()
+	}
+
+	return p
+}
+
+func (p *workerPool) work() {
+	defer p.wg.Done()
+
+	for job := range p.jobs {
+		// jobs still queued once the pool is cancelled are drained without running
+		if p.ctx.Err() != nil {
+			continue
+		}
+
+		if err := job(); err != nil {
+			p.fail(err)
+		}
+	}
+}
+
+func (p *workerPool) fail(err error) {
+	p.mu.Lock()
+	p.errs = append(p.errs, err)
+	p.mu.Unlock()
+
+	if p.mode == FirstError {
+		p.cancel()
+	}
+}
+
+// submit hands a job to the next free worker, returning an error instead once the pool is cancelled
+func (p *workerPool) submit(job func() error) error {
+	select {
+	case p.jobs <- job:
+		return nil
+	case <-p.ctx.Done():
+		if err := p.err(); err != nil {
+			return err
+		}
+
+		return p.ctx.Err()
+	}
+}
+
+// wait stops accepting jobs and waits for the workers to finish, returning the failures according to the error mode.
+// The context's error is returned when it was cancelled with no job failing
+func (p *workerPool) wait() error {
+	close(p.jobs)
+	p.wg.Wait()
+	p.cancel()
+
+	if err := p.err(); err != nil {
+		return err
+	}
+
+	return p.parent.Err()
+}
+
+func (p *workerPool) err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.errs) == 0 {
+		return nil
+	}
+
+	if p.mode == FirstError {
+		return p.errs[0]
+	}
+
+	return errors.Join(p.errs...)
+}
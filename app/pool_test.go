@@ -0,0 +1,139 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+	mock_api "router-location-connecter/api/mocks"
+	"router-location-connecter/output"
+	"router-location-connecter/storage"
+	mock_storage "router-location-connecter/storage/mocks"
+)
+
+func Test_workerPool(t *testing.T) {
+	p := newWorkerPool(context.Background(), 3, CollectAll)
+
+	var running, maxRunning, ran atomic.Int64
+	for range 50 {
+		assert.NoError(t, p.submit(func() error {
+			n := running.Add(1)
+			defer running.Add(-1)
+
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+
+			ran.Add(1)
+
+			return nil
+		}))
+	}
+
+	assert.NoError(t, p.wait())
+	assert.Equal(t, int64(50), ran.Load())
+	assert.LessOrEqual(t, maxRunning.Load(), int64(3))
+}
+
+func Test_workerPool_ErrorModes(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    ErrorMode
+		wantRan int64
+		wantErr string
+	}{
+		{
+			name:    "first error cancels the jobs still to run",
+			mode:    FirstError,
+			wantRan: 1,
+			wantErr: "job 0 failed",
+		},
+		{
+			name:    "collect all runs every job and joins the errors",
+			mode:    CollectAll,
+			wantRan: 3,
+			wantErr: "job 0 failed\njob 1 failed\njob 2 failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a single worker runs the jobs in the order they're submitted
+			p := newWorkerPool(context.Background(), 1, tt.mode)
+
+			var ran atomic.Int64
+			for i := range 3 {
+				_ = p.submit(func() error {
+					ran.Add(1)
+
+					return fmt.Errorf("job %d failed", i)
+				})
+			}
+
+			assert.EqualError(t, p.wait(), tt.wantErr)
+			assert.Equal(t, tt.wantRan, ran.Load())
+		})
+	}
+}
+
+func Test_workerPool_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := newWorkerPool(ctx, 2, CollectAll)
+
+	cancel()
+
+	assert.ErrorIs(t, p.submit(func() error { return nil }), context.Canceled)
+	assert.ErrorIs(t, p.wait(), context.Canceled)
+}
+
+func Test_app_Process_Workers(t *testing.T) {
+	ctx := context.Background()
+
+	// enough routers and locations for several chunks to be written concurrently
+	data := &api.RouterLocationData{}
+	for id := 1; id <= 3*_ingestChunkSize; id++ {
+		data.Routers = append(data.Routers, api.Router{ID: id, Name: fmt.Sprintf("router-%d", id), LocationID: id})
+		data.Locations = append(data.Locations, api.Location{ID: id, Name: fmt.Sprintf("location-%d", id)})
+	}
+
+	t.Run("stores every router and location", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		apiMock := mock_api.NewMockAPI(mockController)
+		apiMock.EXPECT().StreamRouterLocationData(ctx, gomock.Any()).Times(1).DoAndReturn(streamData(data))
+
+		memory := storage.NewMemory()
+		a := NewApp(apiMock, memory, output.NewText(io.Discard), zerolog.Nop(), WithWorkers(4, FirstError))
+		assert.NoError(t, a.Process(ctx))
+
+		routers, err := memory.ListRouters()
+		assert.NoError(t, err)
+		assert.Equal(t, data.Routers, routers)
+
+		locations, err := memory.ListLocations()
+		assert.NoError(t, err)
+		assert.Equal(t, data.Locations, locations)
+	})
+
+	t.Run("fails the ingest with the first failed write", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		apiMock := mock_api.NewMockAPI(mockController)
+		apiMock.EXPECT().StreamRouterLocationData(ctx, gomock.Any()).Times(1).DoAndReturn(streamData(data))
+
+		storageMock := mock_storage.NewMockStorage(mockController)
+		storageMock.EXPECT().AddRouters(gomock.Any()).MinTimes(1).Return(errors.New("WRONGTYPE"))
+		storageMock.EXPECT().AddLocations(gomock.Any()).AnyTimes().Return(nil)
+
+		a := NewApp(apiMock, storageMock, output.NewText(io.Discard), zerolog.Nop(), WithWorkers(4, FirstError))
+		assert.EqualError(t, a.Process(ctx), "store router location data: WRONGTYPE")
+	})
+}
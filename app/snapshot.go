@@ -0,0 +1,51 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"router-location-connecter/api"
+	"router-location-connecter/graph"
+	"router-location-connecter/storage"
+)
+
+// snapshotRetention is how many snapshots are kept and for how long, zero disabling either limit
+type snapshotRetention struct {
+	keep   int
+	maxAge time.Duration
+}
+
+// snapshot stores the data of the router graph as a snapshot and prunes expired snapshots. An error is returned when the
+// snapshot couldn't be saved, failing to prune is only logged as the snapshot has been saved
+func (a *app) snapshot(routerGraph *graph.Graph) error {
+	now := a.now()
+
+	snapshot := storage.NewSnapshot(now, api.RouterLocationData{
+		Routers:   routerGraph.Routers(),
+		Locations: routerGraph.Locations(),
+	})
+
+	if err := a.storage.SaveSnapshot(snapshot); err != nil {
+		return fmt.Errorf("save snapshot %s: %w", snapshot.ID, err)
+	}
+
+	retention := storage.Retention{Keep: a.snapshots.keep}
+	if a.snapshots.maxAge > 0 {
+		retention.Before = now.Add(-a.snapshots.maxAge)
+	}
+
+	pruned, err := a.storage.PruneSnapshots(retention)
+	if err != nil {
+		log.Error().Err(err).Msg("prune snapshots")
+		return nil
+	}
+
+	log.Info().
+		Str("snapshot_id", snapshot.ID).
+		Int("pruned", len(pruned)).
+		Msg("saved snapshot")
+
+	return nil
+}
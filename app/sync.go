@@ -0,0 +1,265 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"router-location-connecter/api"
+	"router-location-connecter/diff"
+	"router-location-connecter/graph"
+	"router-location-connecter/storage"
+	"router-location-connecter/validate"
+)
+
+// Sync processes the latest router location data against what's already in storage, returning what changed since the
+// stored data. Storage is updated incrementally, only the routers, locations and links which changed are written or
+// deleted. The writes go straight to the active data rather than a stage, as a stage starts empty and would need
+// every record rewriting. Partial updates are acceptable as records are written before the links to them and links
+// deleted before the records they link, so a reader never sees a link to a missing location, and a sync failing part
+// way through is finished by the next as each sync compares against what storage holds rather than what the last sync
+// read. When the snapshot can't be saved the changes are returned along with the error
+func (a *app) Sync(ctx context.Context) (*diff.Changes, error) {
+	before, storedLinks, err := a.storedSnapshot()
+	if err != nil {
+		return nil, fmt.Errorf("read stored snapshot: %w", err)
+	}
+
+	routerGraph, err := a.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	after := diff.FromGraph(routerGraph)
+	changes := diff.Compare(before, after)
+
+	if err := a.update(before, after, storedLinks, changes); err != nil {
+		return nil, fmt.Errorf("update storage: %w", err)
+	}
+
+	// storage has been updated, so the changes are returned with the error for them to still be reported
+	if a.snapshots != nil {
+		if err := a.snapshot(routerGraph); err != nil {
+			return changes, err
+		}
+	}
+
+	return changes, nil
+}
+
+// Watch syncs straight away and then on every interval until the context is cancelled, sending the changes of each
+// sync to the sink. Failed syncs are logged and retried on the next interval
+func (a *app) Watch(ctx context.Context, interval time.Duration, sink diff.Sink) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		changes, err := a.Sync(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("sync router location data")
+		}
+
+		if changes != nil && !changes.Empty() {
+			if err := sink.Send(ctx, changes.Events()); err != nil {
+				log.Error().Err(err).Msg("send change events")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// storedSnapshot reads the routers, locations and links currently in storage, returning the stored links as well
+func (a *app) storedSnapshot() (diff.Snapshot, []api.RouterLocationLink, error) {
+	routers, err := a.storage.ListRouters()
+	if err != nil {
+		return diff.Snapshot{}, nil, err
+	}
+
+	locations, err := a.storage.ListLocations()
+	if err != nil {
+		return diff.Snapshot{}, nil, err
+	}
+
+	links, err := a.storage.ListRouterLocationLinks()
+	if err != nil {
+		return diff.Snapshot{}, nil, err
+	}
+
+	snapshot := diff.Snapshot{Routers: routers, Locations: locations}
+	for _, link := range links {
+		snapshot.Links = append(snapshot.Links, diff.LocationPair{SourceID: link.SourceID, DestinationID: link.DestinationID})
+	}
+
+	return snapshot, links, nil
+}
+
+// loader is an api.Handler adding streamed routers and locations to the graph builder and validator without storing them
+type loader struct {
+	builder *graph.Builder
+	// validator is nil when validation is off
+	validator *validate.Validator
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ api.Handler = (*loader)(nil)
+
+func (l *loader) HandleRouter(router *api.Router) error {
+	l.builder.AddRouter(*router)
+
+	if l.validator != nil {
+		l.validator.AddRouter(*router)
+	}
+
+	return nil
+}
+
+func (l *loader) HandleLocation(location *api.Location) error {
+	l.builder.AddLocation(*location)
+
+	if l.validator != nil {
+		l.validator.AddLocation(*location)
+	}
+
+	return nil
+}
+
+// load builds the router graph from the router location data, leaving storage to be updated with what changed
+func (a *app) load(ctx context.Context) (*graph.Graph, error) {
+	l := &loader{
+		builder:   graph.NewBuilder(graph.WithLinkPolicy(a.linkPolicy)),
+		validator: a.newValidator(),
+	}
+
+	if err := a.apiClient.StreamRouterLocationData(ctx, l); err != nil {
+		return nil, fmt.Errorf("get router location data: %w", err)
+	}
+
+	if l.validator != nil {
+		if err := a.reportValidation(l.validator.Report()); err != nil {
+			return nil, err
+		}
+	}
+
+	return buildGraph(l.builder)
+}
+
+// update writes the changes between the stored snapshot and the latest one to storage, in an order keeping every link
+// a reader sees pointing at stored locations
+func (a *app) update(before, after diff.Snapshot, storedLinks []api.RouterLocationLink, changes *diff.Changes) error {
+	if err := a.updateRecords(before, after); err != nil {
+		return err
+	}
+
+	if err := a.updateLinks(after, storedLinks); err != nil {
+		return err
+	}
+
+	for _, router := range changes.RoutersRemoved {
+		if err := a.storage.DeleteRouter(router.ID); err != nil {
+			return err
+		}
+	}
+
+	for _, location := range changes.LocationsRemoved {
+		if err := a.storage.DeleteLocation(location.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateRecords writes the routers and locations of the latest snapshot which aren't stored as they are, so a router
+// renamed or a location whose postcode changed is updated too
+func (a *app) updateRecords(before, after diff.Snapshot) error {
+	storedRouters := make(map[int]api.Router, len(before.Routers))
+	for _, router := range before.Routers {
+		storedRouters[router.ID] = router
+	}
+
+	var routers []api.Router
+	for _, router := range after.Routers {
+		stored, ok := storedRouters[router.ID]
+		if !ok || stored.Name != router.Name || stored.LocationID != router.LocationID ||
+			!slices.Equal(stored.RouterLinks, router.RouterLinks) {
+			routers = append(routers, router)
+		}
+	}
+
+	storedLocations := make(map[int]api.Location, len(before.Locations))
+	for _, location := range before.Locations {
+		storedLocations[location.ID] = location
+	}
+
+	var locations []api.Location
+	for _, location := range after.Locations {
+		if stored, ok := storedLocations[location.ID]; !ok || stored != location {
+			locations = append(locations, location)
+		}
+	}
+
+	if err := a.SaveRouterData(routers); err != nil {
+		return err
+	}
+
+	return a.SaveLocationData(locations)
+}
+
+// updateLinks deletes the stored links which aren't in the latest snapshot and adds those which aren't stored. Links
+// are matched on their unique ID, made from the names of their locations, so the links of a renamed location are
+// replaced
+func (a *app) updateLinks(after diff.Snapshot, storedLinks []api.RouterLocationLink) error {
+	locations := make(map[int]api.Location, len(after.Locations))
+	for _, location := range after.Locations {
+		locations[location.ID] = location
+	}
+
+	latest := make(map[string]api.RouterLocationLink, len(after.Links))
+	for _, pair := range after.Links {
+		link := locationLink(locations[pair.SourceID], locations[pair.DestinationID])
+		latest[link.UniqueID] = link
+	}
+
+	stored := make(map[string]struct{}, len(storedLinks))
+	for _, link := range storedLinks {
+		if current, ok := latest[link.UniqueID]; ok && current == link {
+			stored[link.UniqueID] = struct{}{}
+			continue
+		}
+
+		if err := a.storage.DeleteRouterLocationLink(link.UniqueID); err != nil {
+			return err
+		}
+	}
+
+	for _, pair := range after.Links {
+		link := latest[sortLocations(locations[pair.SourceID].Name, locations[pair.DestinationID].Name)]
+		if _, ok := stored[link.UniqueID]; ok {
+			continue
+		}
+
+		if err := a.addLink(link); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addLink stores a location link, one already stored is left as it is
+func (a *app) addLink(link api.RouterLocationLink) error {
+	if err := a.storage.AddRouterLocationLink(&link); err != nil && !errors.Is(err, storage.ErrConflict) {
+		return err
+	}
+
+	return nil
+}
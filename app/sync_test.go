@@ -0,0 +1,486 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+	mock_api "router-location-connecter/api/mocks"
+	"router-location-connecter/diff"
+	"router-location-connecter/output"
+	"router-location-connecter/storage"
+)
+
+func Test_app_Sync(t *testing.T) {
+	ctx := context.Background()
+
+	mockController := gomock.NewController(t)
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	defer mockController.Finish()
+
+	first := &api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, Name: "Router A", LocationID: 1, RouterLinks: []int{2}},
+			{ID: 2, Name: "Router B", LocationID: 2, RouterLinks: []int{1, 3}},
+			{ID: 3, Name: "Router C", LocationID: 3, RouterLinks: []int{2}},
+		},
+		Locations: []api.Location{
+			{ID: 1, Postcode: "BE12 2ND", Name: "Location A"},
+			{ID: 2, Postcode: "BE12 2ND", Name: "Location B"},
+			{ID: 3, Postcode: "BE13 1EQ", Name: "Location C"},
+		},
+	}
+
+	// router 3 is removed taking the link between locations 2 and 3 with it, and location 1 is renamed
+	second := &api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, Name: "Router A", LocationID: 1, RouterLinks: []int{2}},
+			{ID: 2, Name: "Router B", LocationID: 2, RouterLinks: []int{1}},
+		},
+		Locations: []api.Location{
+			{ID: 1, Postcode: "BE12 2ND", Name: "Location A2"},
+			{ID: 2, Postcode: "BE12 2ND", Name: "Location B"},
+			{ID: 3, Postcode: "BE13 1EQ", Name: "Location C"},
+		},
+	}
+
+	gomock.InOrder(
+		apiMock.EXPECT().StreamRouterLocationData(ctx, gomock.Any()).DoAndReturn(streamData(first)),
+		apiMock.EXPECT().StreamRouterLocationData(ctx, gomock.Any()).DoAndReturn(streamData(second)),
+	)
+
+	memory := storage.NewMemory()
+	a := NewApp(apiMock, memory, output.NewText(io.Discard), zerolog.Nop())
+
+	changes, err := a.Sync(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, changes.RoutersAdded, 3)
+	assert.Len(t, changes.LocationsAdded, 3)
+	assert.Len(t, changes.LinksAdded, 2)
+
+	changes, err = a.Sync(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &diff.Changes{
+		RoutersRemoved: []api.Router{{ID: 3, Name: "Router C", LocationID: 3, RouterLinks: []int{2}}},
+		RouterLinksChanged: []diff.RouterLinksChange{{
+			Router:  api.Router{ID: 2, Name: "Router B", LocationID: 2, RouterLinks: []int{1}},
+			Removed: []int{3},
+		}},
+		LocationsRenamed: []diff.Rename{{
+			Location:     api.Location{ID: 1, Postcode: "BE12 2ND", Name: "Location A2"},
+			PreviousName: "Location A",
+		}},
+		LinksRemoved: []diff.Link{{
+			Source:      api.Location{ID: 2, Postcode: "BE12 2ND", Name: "Location B"},
+			Destination: api.Location{ID: 3, Postcode: "BE13 1EQ", Name: "Location C"},
+		}},
+	}, changes)
+
+	routers, err := memory.ListRouters()
+	assert.NoError(t, err)
+	assert.Len(t, routers, 2)
+
+	// the link of the renamed location is replaced rather than left behind
+	links, err := memory.ListRouterLocationLinks()
+	assert.NoError(t, err)
+	assert.Equal(t, []api.RouterLocationLink{{
+		UniqueID:      "Location A2:Location B",
+		Connection:    "[Location A2] <-> [Location B]",
+		SourceID:      1,
+		DestinationID: 2,
+	}}, links)
+}
+
+// writeRecorder records the IDs of the routers and locations written to the storage it wraps
+type writeRecorder struct {
+	storage.Storage
+	routers   []int
+	locations []int
+}
+
+func (w *writeRecorder) AddRouters(routers []api.Router) error {
+	for _, router := range routers {
+		w.routers = append(w.routers, router.ID)
+	}
+
+	return w.Storage.AddRouters(routers)
+}
+
+func (w *writeRecorder) AddLocations(locations []api.Location) error {
+	for _, location := range locations {
+		w.locations = append(w.locations, location.ID)
+	}
+
+	return w.Storage.AddLocations(locations)
+}
+
+func Test_app_Sync_Incremental(t *testing.T) {
+	ctx := context.Background()
+
+	mockController := gomock.NewController(t)
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	defer mockController.Finish()
+
+	data := &api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, Name: "Router A", LocationID: 1, RouterLinks: []int{2}},
+			{ID: 2, Name: "Router B", LocationID: 2, RouterLinks: []int{1}},
+		},
+		Locations: []api.Location{
+			{ID: 1, Postcode: "BE12 2ND", Name: "Location A"},
+			{ID: 2, Postcode: "BE12 2ND", Name: "Location B"},
+		},
+	}
+
+	memory := storage.NewMemory()
+	for _, router := range data.Routers {
+		assert.NoError(t, memory.AddRouter(&router))
+	}
+	for _, location := range data.Locations {
+		assert.NoError(t, memory.AddLocation(&location))
+	}
+	assert.NoError(t, memory.AddRouterLocationLink(&api.RouterLocationLink{
+		UniqueID:      "Location A:Location B",
+		Connection:    "[Location A] <-> [Location B]",
+		SourceID:      1,
+		DestinationID: 2,
+	}))
+
+	// router 1 is renamed and location 2's postcode changes, neither of which is reported as a change
+	latest := &api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, Name: "Router A2", LocationID: 1, RouterLinks: []int{2}},
+			{ID: 2, Name: "Router B", LocationID: 2, RouterLinks: []int{1}},
+		},
+		Locations: []api.Location{
+			{ID: 1, Postcode: "BE12 2ND", Name: "Location A"},
+			{ID: 2, Postcode: "BE13 1EQ", Name: "Location B"},
+		},
+	}
+
+	apiMock.EXPECT().StreamRouterLocationData(ctx, gomock.Any()).DoAndReturn(streamData(latest))
+
+	recorder := &writeRecorder{Storage: memory}
+	a := NewApp(apiMock, recorder, output.NewText(io.Discard), zerolog.Nop())
+
+	changes, err := a.Sync(ctx)
+	assert.NoError(t, err)
+	assert.True(t, changes.Empty())
+
+	// only the records which differ from those stored are written
+	assert.Equal(t, []int{1}, recorder.routers)
+	assert.Equal(t, []int{2}, recorder.locations)
+
+	routers, err := memory.ListRouters()
+	assert.NoError(t, err)
+	assert.Equal(t, latest.Routers, routers)
+
+	locations, err := memory.ListLocations()
+	assert.NoError(t, err)
+	assert.Equal(t, latest.Locations, locations)
+
+	links, err := memory.ListRouterLocationLinks()
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+}
+
+func Test_app_Sync_FinishesPartialUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	mockController := gomock.NewController(t)
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	defer mockController.Finish()
+
+	data := &api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, Name: "Router A", LocationID: 1, RouterLinks: []int{2}},
+			{ID: 2, Name: "Router B", LocationID: 2, RouterLinks: []int{1}},
+		},
+		Locations: []api.Location{
+			{ID: 1, Postcode: "BE12 2ND", Name: "Location A2"},
+			{ID: 2, Postcode: "BE12 2ND", Name: "Location B"},
+		},
+	}
+
+	// a sync failed after writing the renamed location, leaving the link under the location's previous name
+	memory := storage.NewMemory()
+	assert.NoError(t, memory.AddRouters(data.Routers))
+	assert.NoError(t, memory.AddLocations(data.Locations))
+	assert.NoError(t, memory.AddRouterLocationLink(&api.RouterLocationLink{
+		UniqueID:      "Location A:Location B",
+		Connection:    "[Location A] <-> [Location B]",
+		SourceID:      1,
+		DestinationID: 2,
+	}))
+
+	apiMock.EXPECT().StreamRouterLocationData(ctx, gomock.Any()).DoAndReturn(streamData(data))
+
+	a := NewApp(apiMock, memory, output.NewText(io.Discard), zerolog.Nop())
+
+	changes, err := a.Sync(ctx)
+	assert.NoError(t, err)
+	assert.True(t, changes.Empty())
+
+	links, err := memory.ListRouterLocationLinks()
+	assert.NoError(t, err)
+	assert.Equal(t, []api.RouterLocationLink{{
+		UniqueID:      "Location A2:Location B",
+		Connection:    "[Location A2] <-> [Location B]",
+		SourceID:      1,
+		DestinationID: 2,
+	}}, links)
+}
+
+func Test_app_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mockController := gomock.NewController(t)
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	defer mockController.Finish()
+
+	apiMock.EXPECT().
+		StreamRouterLocationData(ctx, gomock.Any()).
+		Times(1).
+		DoAndReturn(streamData(&api.RouterLocationData{
+			Locations: []api.Location{{ID: 1, Postcode: "BE12 2ND", Name: "Location A"}},
+		}))
+
+	a := NewApp(apiMock, storage.NewMemory(), output.NewText(io.Discard), zerolog.Nop())
+
+	// cancelling once the changes have been sent stops the watch after the first sync
+	var out bytes.Buffer
+	assert.NoError(t, a.Watch(ctx, time.Hour, cancelSink{Sink: diff.NewWriterSink(&out), cancel: cancel}))
+	assert.Equal(t, `{"type":"location_added","location":{"id":1,"postcode":"BE12 2ND","name":"Location A"}}`+"\n", out.String())
+}
+
+func Test_app_Process_Snapshots(t *testing.T) {
+	ctx := context.Background()
+
+	mockController := gomock.NewController(t)
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	defer mockController.Finish()
+
+	data := &api.RouterLocationData{
+		Routers:   []api.Router{{ID: 1, Name: "Router A", LocationID: 1, RouterLinks: []int{}}},
+		Locations: []api.Location{{ID: 1, Postcode: "BE12 2ND", Name: "Location A"}},
+	}
+
+	apiMock.EXPECT().StreamRouterLocationData(ctx, gomock.Any()).Times(3).DoAndReturn(streamData(data))
+
+	memory := storage.NewMemory()
+	a := NewApp(apiMock, memory, output.NewText(io.Discard), zerolog.Nop(), WithSnapshots(2, 0))
+
+	now := time.Date(2024, 3, 14, 23, 38, 46, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		a.now = func() time.Time { return now.Add(time.Duration(i) * time.Minute) }
+		assert.NoError(t, a.Process(ctx))
+	}
+
+	// only the newest two snapshots are kept
+	infos, err := memory.ListSnapshots()
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.SnapshotInfo{
+		{ID: "20240314T233946.000Z", CreatedAt: now.Add(time.Minute), Routers: 1, Locations: 1},
+		{ID: "20240314T234046.000Z", CreatedAt: now.Add(2 * time.Minute), Routers: 1, Locations: 1},
+	}, infos)
+
+	snapshot, err := memory.GetSnapshot(infos[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, *data, snapshot.Data)
+}
+
+func Test_app_Process_SnapshotConflict(t *testing.T) {
+	ctx := context.Background()
+
+	mockController := gomock.NewController(t)
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	defer mockController.Finish()
+
+	data := &api.RouterLocationData{
+		Locations: []api.Location{{ID: 1, Postcode: "BE12 2ND", Name: "Location A"}},
+	}
+
+	apiMock.EXPECT().StreamRouterLocationData(ctx, gomock.Any()).Times(2).DoAndReturn(streamData(data))
+
+	a := NewApp(apiMock, storage.NewMemory(), output.NewText(io.Discard), zerolog.Nop(), WithSnapshots(0, 0))
+
+	// two runs within the same millisecond make snapshots with the same ID, the second failing the run
+	now := time.Date(2024, 3, 14, 23, 38, 46, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	assert.NoError(t, a.Process(ctx))
+
+	err := a.Process(ctx)
+	assert.ErrorIs(t, err, storage.ErrConflict)
+	assert.ErrorContains(t, err, "save snapshot 20240314T233846.000Z")
+}
+
+// cancelSink cancels the context after passing events on to the sink it wraps
+type cancelSink struct {
+	diff.Sink
+	cancel context.CancelFunc
+}
+
+func (s cancelSink) Send(ctx context.Context, events []diff.Event) error {
+	defer s.cancel()
+
+	return s.Sink.Send(ctx, events)
+}
+
+func Test_app_Process_Staged(t *testing.T) {
+	ctx := context.Background()
+
+	mockController := gomock.NewController(t)
+	apiMock := mock_api.NewMockAPI(mockController)
+
+	defer mockController.Finish()
+
+	data := &api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, Name: "Router A", LocationID: 1, RouterLinks: []int{2}},
+			{ID: 2, Name: "Router B", LocationID: 2, RouterLinks: []int{1}},
+		},
+		Locations: []api.Location{
+			{ID: 1, Postcode: "BE12 2ND", Name: "Location A"},
+			{ID: 2, Postcode: "BE12 2ND", Name: "Location B"},
+		},
+	}
+
+	gomock.InOrder(
+		apiMock.EXPECT().StreamRouterLocationData(ctx, gomock.Any()).DoAndReturn(streamData(data)),
+		// the second run fails part way through streaming
+		apiMock.EXPECT().StreamRouterLocationData(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, h api.Handler) error {
+				if err := h.HandleRouter(&api.Router{ID: 3, Name: "Router C", LocationID: 3}); err != nil {
+					return err
+				}
+
+				return errors.New("connection reset by peer")
+			}),
+		apiMock.EXPECT().StreamRouterLocationData(ctx, gomock.Any()).DoAndReturn(streamData(data)),
+	)
+
+	memory := storage.NewMemory()
+
+	var out bytes.Buffer
+	a := NewApp(apiMock, memory, output.NewText(&out), zerolog.Nop())
+
+	assert.NoError(t, a.Process(ctx))
+	assert.EqualError(t, a.Process(ctx), "get router location data: connection reset by peer")
+
+	// the failed run left the data of the first run in place
+	routers, err := memory.ListRouters()
+	assert.NoError(t, err)
+	assert.Equal(t, data.Routers, routers)
+
+	// links kept from the first run aren't output again
+	assert.NoError(t, a.Process(ctx))
+	assert.Equal(t, "[Location A] <-> [Location B]\n", out.String())
+
+	links, err := memory.ListRouterLocationLinks()
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+}
+
+// faultyStager stages in memory, failing the stage's link writes or commit with the errors given
+type faultyStager struct {
+	*storage.Memory
+	linkErr   error
+	commitErr error
+}
+
+func (s *faultyStager) Stage(ctx context.Context) (storage.Stage, error) {
+	stage, err := s.Memory.Stage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &faultyStage{Stage: stage, stager: s}, nil
+}
+
+type faultyStage struct {
+	storage.Stage
+	stager *faultyStager
+}
+
+func (s *faultyStage) AddRouterLocationLink(link *api.RouterLocationLink) error {
+	if s.stager.linkErr != nil {
+		return s.stager.linkErr
+	}
+
+	return s.Stage.AddRouterLocationLink(link)
+}
+
+func (s *faultyStage) Commit(ctx context.Context) error {
+	if s.stager.commitErr != nil {
+		return s.stager.commitErr
+	}
+
+	return s.Stage.Commit(ctx)
+}
+
+func Test_app_Process_Staged_Failures(t *testing.T) {
+	ctx := context.Background()
+
+	data := &api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, Name: "Router A", LocationID: 1, RouterLinks: []int{2}},
+			{ID: 2, Name: "Router B", LocationID: 2, RouterLinks: []int{1}},
+		},
+		Locations: []api.Location{
+			{ID: 1, Postcode: "BE12 2ND", Name: "Location A"},
+			{ID: 2, Postcode: "BE12 2ND", Name: "Location B"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		linkErr   error
+		commitErr error
+		wantErr   string
+	}{
+		{
+			name:    "a link failing to store discards the run",
+			linkErr: storage.ErrUnavailable,
+			wantErr: "calculate link: storage unavailable",
+		},
+		{
+			name:      "a failed commit outputs nothing",
+			commitErr: storage.ErrUnavailable,
+			wantErr:   "commit staged router location data: storage unavailable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			apiMock := mock_api.NewMockAPI(mockController)
+			apiMock.EXPECT().StreamRouterLocationData(ctx, gomock.Any()).DoAndReturn(streamData(data))
+
+			stager := &faultyStager{Memory: storage.NewMemory().(*storage.Memory), linkErr: tt.linkErr, commitErr: tt.commitErr}
+
+			var out bytes.Buffer
+			a := NewApp(apiMock, stager, output.NewText(&out), zerolog.Nop())
+
+			assert.EqualError(t, a.Process(ctx), tt.wantErr)
+			assert.Empty(t, out.String())
+
+			routers, err := stager.ListRouters()
+			assert.NoError(t, err)
+			assert.Empty(t, routers)
+		})
+	}
+}
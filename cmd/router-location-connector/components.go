@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"router-location-connecter/api"
+	"router-location-connecter/graph"
+)
+
+// componentsResult is what components prints, components are ordered largest first so the first is the core
+type componentsResult struct {
+	Components        []componentDetail `json:"components"`
+	IsolatedLocations []api.Location    `json:"isolated_locations"`
+	IsolatedRouters   []routerHop       `json:"isolated_routers"`
+}
+
+// componentDetail is a connected component with its locations and routers looked up
+type componentDetail struct {
+	Locations []api.Location `json:"locations"`
+	Routers   []routerHop    `json:"routers"`
+}
+
+// componentsCommand groups the locations into connected components and lists the locations and routers cut off from
+// everything else. It exits 1 when any locations are cut off from each other and 2 on failure
+func componentsCommand(ctx context.Context, log zerolog.Logger, args []string) int {
+	var (
+		data   graphSource
+		format string
+	)
+
+	fs := flag.NewFlagSet("components", flag.ExitOnError)
+	setUsage(fs, "[flags]", "groups locations into connected components and lists the locations and routers cut off from\n"+
+		"everything, exiting 1 when any locations are cut off from each other")
+	data.register(fs)
+	fs.StringVar(&format, "format", _formatText, "format the components are written in, one of text|json")
+	_ = fs.Parse(args)
+
+	if _, err := parseFormat(format); err != nil {
+		log.Error().Err(err).Msg("invalid components format")
+		return 2
+	}
+
+	routerGraph, err := data.build(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("build router graph")
+		return 2
+	}
+
+	result := findComponents(routerGraph)
+
+	err = writeResult(os.Stdout, format, result, func(w io.Writer) error {
+		return writeComponentsText(w, result)
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("write components")
+		return 2
+	}
+
+	if result.disconnected() {
+		return 1
+	}
+
+	return 0
+}
+
+// findComponents looks up the locations and routers of every component and isolated site
+func findComponents(routerGraph *graph.Graph) *componentsResult {
+	result := &componentsResult{
+		Components:        make([]componentDetail, 0),
+		IsolatedLocations: make([]api.Location, 0),
+		IsolatedRouters:   make([]routerHop, 0),
+	}
+
+	for _, component := range routerGraph.Components() {
+		result.Components = append(result.Components, lookupComponent(routerGraph, component))
+	}
+
+	for _, id := range routerGraph.IsolatedLocations() {
+		location, _ := routerGraph.Location(id)
+		result.IsolatedLocations = append(result.IsolatedLocations, location)
+	}
+
+	for _, id := range routerGraph.IsolatedRouters() {
+		result.IsolatedRouters = append(result.IsolatedRouters, lookupRouter(routerGraph, id))
+	}
+
+	return result
+}
+
+// disconnected reports whether any location is isolated or the locations are split across more than one component
+func (r *componentsResult) disconnected() bool {
+	if len(r.IsolatedLocations) > 0 {
+		return true
+	}
+
+	withLocations := 0
+	for _, component := range r.Components {
+		if len(component.Locations) > 0 {
+			withLocations++
+		}
+	}
+
+	return withLocations > 1
+}
+
+// lookupComponent looks up the locations and routers of a component
+func lookupComponent(routerGraph *graph.Graph, component graph.Component) componentDetail {
+	detail := componentDetail{
+		Locations: make([]api.Location, 0, len(component.Locations)),
+		Routers:   make([]routerHop, 0, len(component.Routers)),
+	}
+
+	for _, id := range component.Locations {
+		location, _ := routerGraph.Location(id)
+		detail.Locations = append(detail.Locations, location)
+	}
+
+	for _, id := range component.Routers {
+		detail.Routers = append(detail.Routers, lookupRouter(routerGraph, id))
+	}
+
+	return detail
+}
+
+// lookupRouter returns a router with the location it's at, the location is empty when it doesn't exist
+func lookupRouter(routerGraph *graph.Graph, id int) routerHop {
+	router, _ := routerGraph.Router(id)
+	location, _ := routerGraph.Location(router.LocationID)
+
+	return routerHop{Router: router, Location: location}
+}
+
+func writeComponentsText(w io.Writer, result *componentsResult) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%d components\n", len(result.Components))
+
+	for i, component := range result.Components {
+		fmt.Fprintf(&b, "\ncomponent %d, %s, %s\n", i+1,
+			plural(len(component.Locations), "location"), plural(len(component.Routers), "router"))
+
+		for _, location := range component.Locations {
+			fmt.Fprintf(&b, "  [%s] (%s)\n", location.Name, location.Postcode)
+		}
+
+		for _, hop := range component.Routers {
+			fmt.Fprintf(&b, "  %s\n", routerText(hop))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n%s isolated\n", plural(len(result.IsolatedLocations), "location"))
+	for _, location := range result.IsolatedLocations {
+		fmt.Fprintf(&b, "  [%s] (%s)\n", location.Name, location.Postcode)
+	}
+
+	fmt.Fprintf(&b, "\n%s isolated\n", plural(len(result.IsolatedRouters), "router"))
+	for _, hop := range result.IsolatedRouters {
+		fmt.Fprintf(&b, "  %s\n", routerText(hop))
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// routerText describes a router with its location, routers at a location which doesn't exist are marked unknown
+func routerText(hop routerHop) string {
+	if hop.Location.Name == "" {
+		return fmt.Sprintf("%s [unknown location %d]", hop.Router.Name, hop.Router.LocationID)
+	}
+
+	return fmt.Sprintf("%s [%s]", hop.Router.Name, hop.Location.Name)
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+
+	return fmt.Sprintf("%d %ss", n, noun)
+}
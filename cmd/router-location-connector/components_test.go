@@ -0,0 +1,123 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+	"router-location-connecter/graph"
+)
+
+func Test_findComponents(t *testing.T) {
+	sample := sampleData()
+
+	tests := []struct {
+		name             string
+		data             *api.RouterLocationData
+		want             *componentsResult
+		wantDisconnected bool
+	}{
+		{
+			name: "lists the components largest first with the isolated sites",
+			data: sample,
+			want: &componentsResult{
+				Components: []componentDetail{
+					{
+						Locations: locationsOf(sample, 4, 5, 7, 8),
+						Routers: []routerHop{
+							hopOf(sample, 3), hopOf(sample, 4), hopOf(sample, 9),
+							hopOf(sample, 10), hopOf(sample, 14), hopOf(sample, 15),
+						},
+					},
+					{Locations: locationsOf(sample, 2, 6), Routers: []routerHop{hopOf(sample, 8), hopOf(sample, 11)}},
+					{Locations: locationsOf(sample, 3), Routers: []routerHop{hopOf(sample, 5), hopOf(sample, 6), hopOf(sample, 7)}},
+					{Locations: locationsOf(sample, 1), Routers: []routerHop{hopOf(sample, 1), hopOf(sample, 2)}},
+				},
+				IsolatedLocations: locationsOf(sample, 1, 3),
+				IsolatedRouters:   []routerHop{hopOf(sample, 1), hopOf(sample, 2)},
+			},
+			wantDisconnected: true,
+		},
+		{
+			name: "is connected when every location is in one component",
+			data: &api.RouterLocationData{
+				Routers: []api.Router{
+					{ID: 1, Name: "Router A", LocationID: 1, RouterLinks: []int{2}},
+					{ID: 2, Name: "Router B", LocationID: 2, RouterLinks: []int{1}},
+				},
+				Locations: []api.Location{
+					{ID: 1, Postcode: "BE12 2ND", Name: "Location A"},
+					{ID: 2, Postcode: "BE13 1EQ", Name: "Location B"},
+				},
+			},
+			want: &componentsResult{
+				Components: []componentDetail{{
+					Locations: []api.Location{
+						{ID: 1, Postcode: "BE12 2ND", Name: "Location A"},
+						{ID: 2, Postcode: "BE13 1EQ", Name: "Location B"},
+					},
+					Routers: []routerHop{
+						{
+							Router:   api.Router{ID: 1, Name: "Router A", LocationID: 1, RouterLinks: []int{2}},
+							Location: api.Location{ID: 1, Postcode: "BE12 2ND", Name: "Location A"},
+						},
+						{
+							Router:   api.Router{ID: 2, Name: "Router B", LocationID: 2, RouterLinks: []int{1}},
+							Location: api.Location{ID: 2, Postcode: "BE13 1EQ", Name: "Location B"},
+						},
+					},
+				}},
+				IsolatedLocations: []api.Location{},
+				IsolatedRouters:   []routerHop{},
+			},
+		},
+		{
+			name: "has no components without data",
+			data: &api.RouterLocationData{},
+			want: &componentsResult{
+				Components:        []componentDetail{},
+				IsolatedLocations: []api.Location{},
+				IsolatedRouters:   []routerHop{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findComponents(newGraph(t, tt.data))
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantDisconnected, got.disconnected())
+		})
+	}
+}
+
+func Test_lookupComponent(t *testing.T) {
+	sample := sampleData()
+
+	tests := []struct {
+		name      string
+		component graph.Component
+		want      componentDetail
+	}{
+		{
+			name:      "looks up the locations and routers with their locations",
+			component: graph.Component{Locations: []int{2, 6}, Routers: []int{8, 11}},
+			want:      componentDetail{Locations: locationsOf(sample, 2, 6), Routers: []routerHop{hopOf(sample, 8), hopOf(sample, 11)}},
+		},
+		{
+			name:      "leaves unknown locations and routers empty",
+			component: graph.Component{Locations: []int{99}, Routers: []int{99}},
+			want:      componentDetail{Locations: []api.Location{{}}, Routers: []routerHop{{}}},
+		},
+		{
+			name:      "is empty for an empty component",
+			component: graph.Component{},
+			want:      componentDetail{Locations: []api.Location{}, Routers: []routerHop{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, lookupComponent(newGraph(t, sample), tt.component))
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"router-location-connecter/api"
+	"router-location-connecter/diff"
+	"router-location-connecter/graph"
+	"router-location-connecter/storage"
+)
+
+// _snapshotArgPrefix marks a diff argument as the ID of a snapshot in storage
+const _snapshotArgPrefix = "snapshot:"
+
+// diffCommand compares two router location data sets, exiting 0 when they're the same, 1 when they differ and 2 when
+// they can't be compared, as diff(1) does
+func diffCommand(ctx context.Context, log zerolog.Logger, args []string) int {
+	var (
+		source    sourceFlags
+		graphOpts graphFlags
+		store     storageFlags
+		format    string
+	)
+
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	setUsage(fs, "[flags] <before> <after>", "before and after are base urls as taken by -base-url, paths of snapshot files, or\n"+
+		"snapshot:<id> for a snapshot in storage. Exits 1 when they differ")
+	source.registerClient(fs)
+	graphOpts.register(fs)
+	fs.StringVar(&format, "format", diff.FormatText, "format the changes are written in, one of text|json")
+	store.register(fs, "storage backend snapshot:<id> arguments are read from, one of memory|redis")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	if _, err := diff.ParseFormat(format); err != nil {
+		log.Error().Err(err).Msg("invalid diff format")
+		return 2
+	}
+
+	policy, err := graphOpts.policy()
+	if err != nil {
+		log.Error().Err(err).Msg("invalid link policy")
+		return 2
+	}
+
+	var storageClient storage.Storage
+	snapshots := make([]diff.Snapshot, 0, 2)
+
+	for _, arg := range fs.Args() {
+		var routerGraph *graph.Graph
+
+		if id, ok := strings.CutPrefix(arg, _snapshotArgPrefix); ok {
+			if storageClient == nil {
+				if storageClient, err = store.open(ctx); err != nil {
+					log.Error().Err(err).Msg(_errStorage)
+					return 2
+				}
+
+				defer func() {
+					_ = storageClient.Close()
+				}()
+			}
+
+			routerGraph, err = snapshotGraph(storageClient, id, policy)
+		} else {
+			routerGraph, err = sourceGraph(ctx, source.clientFor(arg), policy)
+		}
+
+		if err != nil {
+			log.Error().Err(err).Str("source", arg).Msg("read router location data")
+			return 2
+		}
+
+		snapshots = append(snapshots, diff.FromGraph(routerGraph))
+	}
+
+	changes := diff.Compare(snapshots[0], snapshots[1])
+	if err := changes.Write(os.Stdout, format); err != nil {
+		log.Error().Err(err).Msg("write changes")
+		return 2
+	}
+
+	if changes.Empty() {
+		return 0
+	}
+
+	return 1
+}
+
+// sourceGraph builds the router graph from the data read by the api client
+func sourceGraph(ctx context.Context, client api.API, policy graph.LinkPolicy) (*graph.Graph, error) {
+	builder := graph.NewBuilder(graph.WithLinkPolicy(policy))
+	if err := client.StreamRouterLocationData(ctx, builder); err != nil {
+		return nil, fmt.Errorf("get router location data: %w", err)
+	}
+
+	return builder.Build()
+}
+
+// snapshotGraph builds the router graph from a snapshot in storage
+func snapshotGraph(s storage.Storage, id string, policy graph.LinkPolicy) (*graph.Graph, error) {
+	snapshot, err := s.GetSnapshot(id)
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot: %w", err)
+	}
+
+	return graph.New(&snapshot.Data, graph.WithLinkPolicy(policy))
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"router-location-connecter/api"
+	"router-location-connecter/app"
+	"router-location-connecter/graph"
+	"router-location-connecter/output"
+	"router-location-connecter/storage"
+)
+
+// sourceFlags select where router location data is read from and how, every command reading data registers them
+type sourceFlags struct {
+	baseURL     string
+	maxRetries  int
+	timeout     int64
+	pageLimit   int
+	maxBodySize int64
+}
+
+func (f *sourceFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.baseURL, "base-url", "https://my-json-server.typicode.com/marcuzh/router_location_test_api/db", "base url to get router location data, a file path, file:// url or - for stdin reads a local snapshot")
+	f.registerClient(fs)
+}
+
+// registerClient registers every source flag but the base url, for commands taking their sources as arguments
+func (f *sourceFlags) registerClient(fs *flag.FlagSet) {
+	fs.IntVar(&f.maxRetries, "retries", 3, "max retries")
+	fs.IntVar(&f.pageLimit, "page-limit", 0, "fetch the routers and locations collections in pages of this size rather than the combined /db document, 0 disables")
+	fs.Int64Var(&f.maxBodySize, "max-body-size", 0, "max size in bytes of router location data read, 0 disables")
+	fs.Int64Var(&f.timeout, "timeout", 20, "time in seconds")
+}
+
+// client initializes the api client reading router location data
+func (f *sourceFlags) client() api.API {
+	return f.clientFor(f.baseURL)
+}
+
+// clientFor initializes an api client reading router location data from the base url
+func (f *sourceFlags) clientFor(baseURL string) api.API {
+	return api.New(api.WithMaxRetries(f.maxRetries),
+		api.WithBaseURL(baseURL),
+		api.WithTimeout(time.Duration(f.timeout)*time.Second),
+		api.WithPageLimit(f.pageLimit),
+		api.WithMaxBodySize(f.maxBodySize))
+}
+
+// graphFlags configure how the router graph is built
+type graphFlags struct {
+	linkPolicy string
+}
+
+func (f *graphFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.linkPolicy, "link-policy", string(graph.RequireBoth), "how one-sided router links are handled, one of require-both|either|strict")
+}
+
+func (f *graphFlags) policy() (graph.LinkPolicy, error) {
+	return graph.ParseLinkPolicy(f.linkPolicy)
+}
+
+// graphSource reads the router graph from the upstream api or, with from-storage, from the data held in storage
+type graphSource struct {
+	source      sourceFlags
+	graph       graphFlags
+	store       storageFlags
+	fromStorage bool
+}
+
+func (f *graphSource) register(fs *flag.FlagSet) {
+	f.source.register(fs)
+	f.graph.register(fs)
+	f.store.register(fs, "storage backend from-storage reads from, one of memory|redis")
+	fs.BoolVar(&f.fromStorage, "from-storage", false, "build the router graph from the data in storage rather than the upstream api")
+}
+
+// build reads the router location data and builds the router graph
+func (f *graphSource) build(ctx context.Context) (*graph.Graph, error) {
+	policy, err := f.graph.policy()
+	if err != nil {
+		return nil, err
+	}
+
+	if !f.fromStorage {
+		return sourceGraph(ctx, f.source.client(), policy)
+	}
+
+	storageClient, err := f.store.open(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", _errStorage, err)
+	}
+
+	defer func() {
+		_ = storageClient.Close()
+	}()
+
+	routers, err := storageClient.ListRouters()
+	if err != nil {
+		return nil, fmt.Errorf("list routers: %w", err)
+	}
+
+	locations, err := storageClient.ListLocations()
+	if err != nil {
+		return nil, fmt.Errorf("list locations: %w", err)
+	}
+
+	return graph.New(&api.RouterLocationData{Routers: routers, Locations: locations}, graph.WithLinkPolicy(policy))
+}
+
+// listFlag collects every value of a flag given more than once
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// outputFlags select the format and destination of location links output
+type outputFlags struct {
+	format       string
+	path         string
+	routerDetail bool
+}
+
+func (f *outputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.format, "output-format", output.FormatText, "format location links are written in, one of text|json|ndjson|csv|tsv|dot|mermaid|graphml|gexf")
+	fs.StringVar(&f.path, "output", "", "file path to write output to, stdout when empty")
+	fs.BoolVar(&f.routerDetail, "router-detail", false, "draw routers grouped by location rather than just locations, for dot and mermaid output")
+}
+
+// emitter opens the output destination and initializes an emitter writing to it, close must be called once done
+func (f *outputFlags) emitter() (emitter output.Emitter, close func() error, err error) {
+	out := os.Stdout
+	close = func() error { return nil }
+
+	if f.path != "" {
+		if out, err = os.Create(f.path); err != nil {
+			return nil, nil, fmt.Errorf("create output file: %w", err)
+		}
+
+		close = out.Close
+	}
+
+	emitter, err = output.New(f.format, out, output.WithRouterDetail(f.routerDetail))
+	if err != nil {
+		_ = close()
+
+		return nil, nil, err
+	}
+
+	return emitter, close, nil
+}
+
+// ingestFlags configure how router location data is written to storage
+type ingestFlags struct {
+	workers     int
+	workerError string
+}
+
+func (f *ingestFlags) register(fs *flag.FlagSet) {
+	fs.IntVar(&f.workers, "workers", 4, "how many chunks of routers and locations are written to storage concurrently")
+	fs.StringVar(&f.workerError, "worker-errors", string(app.CollectAll), "how failed writes are handled, one of first|all, first cancels the writes still to run")
+}
+
+// option returns the app option for the ingest flags
+func (f *ingestFlags) option() (app.Option, error) {
+	mode, err := app.ParseErrorMode(f.workerError)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.workers < 1 {
+		return nil, fmt.Errorf("workers must be at least 1, got %d", f.workers)
+	}
+
+	return app.WithWorkers(f.workers, mode), nil
+}
+
+// storageFlags select the storage backend, every command using storage registers them
+type storageFlags struct {
+	storageType       string
+	redisPrefix       string
+	redisBatchSize    int
+	redisPoolSize     int
+	redisMinIdleConns int
+}
+
+func (f *storageFlags) register(fs *flag.FlagSet, usage string) {
+	fs.StringVar(&f.storageType, "storage", _storageRedis, usage)
+	fs.StringVar(&f.redisPrefix, "redis-prefix", storage.DefaultPrefix, "prefix every redis key is put under, so a shared redis server is only ever touched within it")
+	fs.IntVar(&f.redisBatchSize, "redis-batch-size", storage.DefaultBatchSize, "how many routers or locations are written to redis per pipeline")
+	fs.IntVar(&f.redisPoolSize, "redis-pool-size", 0, "most connections opened to redis, 0 uses 10 per CPU")
+	fs.IntVar(&f.redisMinIdleConns, "redis-min-idle-conns", 0, "connections to redis kept open while idle")
+}
+
+// open initializes the selected storage backend
+func (f *storageFlags) open(ctx context.Context) (storage.Storage, error) {
+	return newStorage(ctx, f.storageType,
+		storage.WithPrefix(f.redisPrefix),
+		storage.WithBatchSize(f.redisBatchSize),
+		storage.WithPoolSize(f.redisPoolSize),
+		storage.WithMinIdleConns(f.redisMinIdleConns))
+}
+
+// newStorage initializes the storage backend of the given type, the options only apply to redis
+func newStorage(ctx context.Context, storageType string, opts ...storage.Option) (storage.Storage, error) {
+	switch storageType {
+	case _storageMemory:
+		return storage.NewMemory(), nil
+	case _storageRedis:
+		// we don't pass these in as flags as we ideally would want to create a Kubernetes secret,
+		// mount this secret into your Pods where the application
+		//can read them as environmental variables
+		redisURL := getEnv("REDIS_URL", "localhost:6379")
+
+		redisPWD := getEnv("REDIS_PASSWORD", "")
+
+		return storage.New(ctx, redisURL, redisPWD, opts...)
+	default:
+		return nil, fmt.Errorf("%s: %q", _errStorageType, storageType)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// formats results of the graph commands are written in
+const (
+	_formatText = "text"
+	_formatJSON = "json"
+)
+
+// parseFormat checks a -format flag value is a format results are written in
+func parseFormat(format string) (string, error) {
+	switch format {
+	case _formatText, _formatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown format %q, must be one of %s|%s", format, _formatText, _formatJSON)
+	}
+}
+
+// writeResult writes the result as indented JSON, or as text with the command's text writer
+func writeResult(w io.Writer, format string, result any, text func(io.Writer) error) error {
+	switch format {
+	case _formatText:
+		return text(w)
+	case _formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(result)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr string
+	}{
+		{name: "accepts text", format: "text", want: _formatText},
+		{name: "accepts json", format: "json", want: _formatJSON},
+		{name: "rejects anything else", format: "yaml", wantErr: `unknown format "yaml", must be one of text|json`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFormat(tt.format)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_writeResult(t *testing.T) {
+	result := struct {
+		Name string `json:"name"`
+	}{Name: "cdn10"}
+
+	text := func(w io.Writer) error {
+		_, err := io.WriteString(w, "cdn10\n")
+		return err
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr string
+	}{
+		{name: "writes text with the text writer", format: _formatText, want: "cdn10\n"},
+		{name: "writes indented json", format: _formatJSON, want: "{\n  \"name\": \"cdn10\"\n}\n"},
+		{name: "fails on an unknown format", format: "yaml", wantErr: `unknown format "yaml"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+
+			err := writeResult(&b, tt.format, result, text)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, b.String())
+		})
+	}
+}
@@ -3,34 +3,53 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
+	"io"
 	"os"
-	"time"
+	"strings"
 
 	"github.com/rs/zerolog"
-
-	"router-location-connecter/api"
-	"router-location-connecter/app"
-	"router-location-connecter/storage"
 )
 
 const (
-	_errRedisClient = "redis client initialization error"
+	_errStorage     = "storage initialization error"
+	_errStorageType = "unknown storage type"
 	_appName        = "router-location-connector"
-)
 
-var (
-	maxRetries         int
-	baseURL            string
-	timeout            int64
-	redisURL, redisPWD string
-	persistData        bool
+	_storageMemory = "memory"
+	_storageRedis  = "redis"
+
+	// _defaultCommand runs when no command is given, so flag-only invocations keep working
+	_defaultCommand = "run"
+
+	// _exitCodes is the exit code convention every command follows, like diff(1)
+	_exitCodes = "exit codes:\n" +
+		"  0  success\n" +
+		"  1  the command ran and found what it reports: data-quality errors, differences, unconnected locations,\n" +
+		"     single points of failure or lost location links\n" +
+		"  2  the command failed: invalid flags or arguments, unreadable data, storage errors or unwritable output\n"
 )
 
-func init() {
-	flag.StringVar(&baseURL, "base-url", "https://my-json-server.typicode.com/marcuzh/router_location_test_api/db", "base url to get router location data")
-	flag.IntVar(&maxRetries, "retries", 3, "max retries")
-	flag.Int64Var(&timeout, "timeout", 20, "time in seconds")
-	flag.BoolVar(&persistData, "persist-data", false, "keep router location data between runs")
+// command is a subcommand of the CLI, run parses the command's own flags from args and returns the exit code
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, log zerolog.Logger, args []string) int
+}
+
+var commands = []command{
+	{name: "run", summary: "store router location data and output the links between locations (default)", run: runCommand},
+	{name: "validate", summary: "check router location data quality without touching storage", run: validateCommand},
+	{name: "export", summary: "output the links between locations using in-memory storage", run: exportCommand},
+	{name: "diff", summary: "compare two router location data sets", run: diffCommand},
+	{name: "serve", summary: "serve location links over a REST API", run: serveCommand},
+	{name: "snapshots", summary: "list the snapshots of router location data in storage", run: snapshotsCommand},
+	{name: "path", summary: "find the shortest paths between two locations", run: pathCommand},
+	{name: "components", summary: "group locations into connected components and list isolated sites", run: componentsCommand},
+	{name: "spof", summary: "list the routers, locations and links which are single points of failure", run: spofCommand},
+	{name: "simulate", summary: "simulate routers, router links or locations failing and print what's lost", run: simulateCommand},
+	{name: "rank", summary: "rank locations or routers by centrality to list the most critical", run: rankCommand},
+	{name: "query", summary: "look up a router or location and what it's linked to", run: queryCommand},
 }
 
 func main() {
@@ -38,44 +57,57 @@ func main() {
 
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
 
-	log := zerolog.New(os.Stdout).With().
+	// logs are written to stderr so stdout only contains the command's output
+	log := zerolog.New(os.Stderr).With().
 		Timestamp().
 		Str("app_name", _appName).
 		Logger()
 
-	flag.Parse()
-
-	apiClient := api.New(api.WithMaxRetries(maxRetries),
-		api.WithBaseURL(baseURL),
-		api.WithTimeout(time.Duration(timeout)*time.Second))
+	os.Exit(dispatch(ctx, log, os.Args[1:]))
+}
 
-	// we don't pass these in as flags as we ideally would want to create a Kubernetes secret,
-	// mount this secret into your Pods where the application
-	//can read them as environmental variables
-	redisURL = getEnv("REDIS_URL", "localhost:6379")
+// dispatch runs the command named by the first argument, the default command runs when it's missing or a flag
+func dispatch(ctx context.Context, log zerolog.Logger, args []string) int {
+	name := _defaultCommand
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-	redisPWD = getEnv("REDIS_PASSWORD", "")
+	if name == "help" {
+		usage(os.Stdout)
+		return 0
+	}
 
-	redisClient, err := storage.New(ctx, redisURL, redisPWD)
-	if err != nil {
-		log.Panic().Err(err).Msg(_errRedisClient)
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(ctx, log, args)
+		}
 	}
 
-	runner := app.NewApp(apiClient, redisClient, log)
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage(os.Stderr)
 
-	runner.Process(ctx)
+	return 2
+}
 
-	// Close the Redis client after finishing
-	if !persistData {
-		if err := redisClient.FlushAll(ctx); err != nil {
-			log.Error().Err(err).Msg("error flushing redis store")
-		}
-	}
+// usage lists the commands, each command's flags are listed by running it with -h
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: %s [command] [flags]\n\ncommands:\n", _appName)
 
-	if err := redisClient.Close(); err != nil {
-		log.Error().Err(err).Msg("error closing redis conn")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
 	}
 
+	fmt.Fprintf(w, "\nrun '%s <command> -h' for the command's flags\n\n%s", _appName, _exitCodes)
+}
+
+// setUsage sets the usage of a command's flags, its synopsis and description followed by its flags and the exit codes
+func setUsage(fs *flag.FlagSet, synopsis, description string) {
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s %s %s\n\n%s\n\n", _appName, fs.Name(), synopsis, description)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\n%s", _exitCodes)
+	}
 }
 
 // getEnv gets any environment variables that are set
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_setUsage(t *testing.T) {
+	var b bytes.Buffer
+
+	fs := flag.NewFlagSet("path", flag.ContinueOnError)
+	fs.SetOutput(&b)
+	setUsage(fs, "[flags] <from> <to>", "finds paths")
+	fs.Bool("all", false, "list every path")
+
+	fs.Usage()
+
+	assert.Equal(t, "usage: router-location-connector path [flags] <from> <to>\n\n"+
+		"finds paths\n\n"+
+		"  -all\n    \tlist every path\n\n"+
+		_exitCodes, b.String())
+}
+
+func Test_usage(t *testing.T) {
+	var b bytes.Buffer
+
+	usage(&b)
+
+	assert.Contains(t, b.String(), "\n  path       find the shortest paths between two locations\n")
+	assert.Contains(t, b.String(), _exitCodes)
+}
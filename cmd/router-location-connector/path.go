@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"router-location-connecter/api"
+	"router-location-connecter/graph"
+)
+
+// pathResult is what path prints, each path being a shortest path so all of them have the same number of hops
+type pathResult struct {
+	From          api.Location     `json:"from"`
+	To            api.Location     `json:"to"`
+	RouterPaths   [][]routerHop    `json:"router_paths"`
+	LocationPaths [][]api.Location `json:"location_paths"`
+}
+
+// routerHop is a router on a router path with the location it's at
+type routerHop struct {
+	Router   api.Router   `json:"router"`
+	Location api.Location `json:"location"`
+}
+
+// pathCommand finds the shortest router and location paths between two locations. Like diff it exits 0 when a path
+// exists, 1 when the locations aren't connected and 2 on failure
+func pathCommand(ctx context.Context, log zerolog.Logger, args []string) int {
+	var (
+		data   graphSource
+		all    bool
+		format string
+	)
+
+	fs := flag.NewFlagSet("path", flag.ExitOnError)
+	setUsage(fs, "[flags] <from> <to>", "from and to are locations given by ID, name or postcode. Exits 1 when they aren't connected")
+	data.register(fs)
+	fs.BoolVar(&all, "all", false, "list every equal-cost shortest path rather than the first")
+	fs.StringVar(&format, "format", _formatText, "format the paths are written in, one of text|json")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	if _, err := parseFormat(format); err != nil {
+		log.Error().Err(err).Msg("invalid path format")
+		return 2
+	}
+
+	routerGraph, err := data.build(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("build router graph")
+		return 2
+	}
+
+	result, err := findPaths(routerGraph, fs.Arg(0), fs.Arg(1), all)
+	if err != nil {
+		log.Error().Err(err).Msg("find location")
+		return 2
+	}
+
+	err = writeResult(os.Stdout, format, result, func(w io.Writer) error {
+		return writePathsText(w, result)
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("write paths")
+		return 2
+	}
+
+	if len(result.RouterPaths) == 0 && len(result.LocationPaths) == 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// findPaths looks up both locations and the shortest paths between them, every equal-cost path when all is set
+func findPaths(routerGraph *graph.Graph, fromQuery, toQuery string, all bool) (*pathResult, error) {
+	from, err := routerGraph.FindLocation(fromQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := routerGraph.FindLocation(toQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	max := 1
+	if all {
+		max = 0
+	}
+
+	result := &pathResult{
+		From:          from,
+		To:            to,
+		RouterPaths:   make([][]routerHop, 0),
+		LocationPaths: make([][]api.Location, 0),
+	}
+
+	for _, ids := range routerGraph.RouterPaths(from.ID, to.ID, max) {
+		path := make([]routerHop, 0, len(ids))
+		for _, id := range ids {
+			path = append(path, lookupRouter(routerGraph, id))
+		}
+
+		result.RouterPaths = append(result.RouterPaths, path)
+	}
+
+	for _, ids := range routerGraph.LocationPaths(from.ID, to.ID, max) {
+		path := make([]api.Location, 0, len(ids))
+		for _, id := range ids {
+			location, _ := routerGraph.Location(id)
+			path = append(path, location)
+		}
+
+		result.LocationPaths = append(result.LocationPaths, path)
+	}
+
+	return result, nil
+}
+
+func writePathsText(w io.Writer, result *pathResult) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "from [%s] (%s) to [%s] (%s)\n", result.From.Name, result.From.Postcode, result.To.Name, result.To.Postcode)
+
+	if len(result.RouterPaths) == 0 {
+		b.WriteString("\nno router path\n")
+	} else {
+		fmt.Fprintf(&b, "\nrouter path, %s\n", hopCount(len(result.RouterPaths[0])-1))
+
+		for _, path := range result.RouterPaths {
+			hops := make([]string, 0, len(path))
+			for _, hop := range path {
+				hops = append(hops, fmt.Sprintf("%s [%s]", hop.Router.Name, hop.Location.Name))
+			}
+
+			fmt.Fprintf(&b, "  %s\n", strings.Join(hops, " -> "))
+		}
+	}
+
+	if len(result.LocationPaths) == 0 {
+		b.WriteString("\nno location path\n")
+	} else {
+		fmt.Fprintf(&b, "\nlocation path, %s\n", hopCount(len(result.LocationPaths[0])-1))
+
+		for _, path := range result.LocationPaths {
+			hops := make([]string, 0, len(path))
+			for _, location := range path {
+				hops = append(hops, fmt.Sprintf("[%s]", location.Name))
+			}
+
+			fmt.Fprintf(&b, "  %s\n", strings.Join(hops, " -> "))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func hopCount(hops int) string {
+	if hops == 1 {
+		return "1 hop"
+	}
+
+	return fmt.Sprintf("%d hops", hops)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+	"router-location-connecter/graph"
+)
+
+// sampleData mirrors the data served by the mock server in config/initializerJson.json
+func sampleData() *api.RouterLocationData {
+	return &api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, Name: "citadel-01", LocationID: 1, RouterLinks: []int{1}},
+			{ID: 2, Name: "citadel-02", LocationID: 1, RouterLinks: []int{}},
+			{ID: 3, Name: "core-07", LocationID: 7, RouterLinks: []int{15}},
+			{ID: 4, Name: "hybrid-x022", LocationID: 4, RouterLinks: []int{14}},
+			{ID: 5, Name: "meta-04", LocationID: 3, RouterLinks: []int{6, 7}},
+			{ID: 6, Name: "universal-16", LocationID: 3, RouterLinks: []int{5}},
+			{ID: 7, Name: "prod", LocationID: 3, RouterLinks: []int{5}},
+			{ID: 8, Name: "custprod-01", LocationID: 6, RouterLinks: []int{11}},
+			{ID: 9, Name: "edgesrv-01", LocationID: 8, RouterLinks: []int{14, 15}},
+			{ID: 10, Name: "proxyA", LocationID: 5, RouterLinks: []int{14}},
+			{ID: 11, Name: "proxyB", LocationID: 2, RouterLinks: []int{8}},
+			{ID: 14, Name: "cdn10", LocationID: 4, RouterLinks: []int{4, 9, 10}},
+			{ID: 15, Name: "cdn20", LocationID: 7, RouterLinks: []int{3, 9}},
+		},
+		Locations: []api.Location{
+			{ID: 1, Postcode: "BE12 2ND", Name: "Birmingham Motorcycle Museum"},
+			{ID: 2, Postcode: "BE12 2ND", Name: "Birmingham Hippodrome"},
+			{ID: 3, Postcode: "BE13 1EQ", Name: "Winterbourne House"},
+			{ID: 4, Postcode: "LA10 1DX", Name: "Lancaster Brewery"},
+			{ID: 5, Postcode: "LA10 7QP", Name: "Lancaster University"},
+			{ID: 6, Postcode: "LA10 9FL", Name: "Williamson Park"},
+			{ID: 7, Postcode: "LA11 1DX", Name: "Lancaster Castle"},
+			{ID: 8, Postcode: "LE13 2SW", Name: "Loughborough University"},
+		},
+	}
+}
+
+// diamondData has two equal-cost paths between locations 1 and 4, through 2 or 3
+func diamondData() *api.RouterLocationData {
+	return &api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, Name: "r1", LocationID: 1, RouterLinks: []int{2, 3}},
+			{ID: 2, Name: "r2", LocationID: 2, RouterLinks: []int{1, 4}},
+			{ID: 3, Name: "r3", LocationID: 3, RouterLinks: []int{1, 4}},
+			{ID: 4, Name: "r4", LocationID: 4, RouterLinks: []int{2, 3}},
+		},
+		Locations: []api.Location{
+			{ID: 1, Postcode: "AA1 1AA", Name: "one"},
+			{ID: 2, Postcode: "AA2 2AA", Name: "two"},
+			{ID: 3, Postcode: "AA3 3AA", Name: "three"},
+			{ID: 4, Postcode: "AA4 4AA", Name: "four"},
+		},
+	}
+}
+
+func newGraph(t *testing.T, data *api.RouterLocationData) *graph.Graph {
+	t.Helper()
+
+	routerGraph, err := graph.New(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return routerGraph
+}
+
+// hopOf returns the router with the ID in the data and the location it's at
+func hopOf(data *api.RouterLocationData, id int) routerHop {
+	var hop routerHop
+	for _, router := range data.Routers {
+		if router.ID == id {
+			hop.Router = router
+		}
+	}
+
+	for _, location := range data.Locations {
+		if location.ID == hop.Router.LocationID {
+			hop.Location = location
+		}
+	}
+
+	return hop
+}
+
+// locationsOf returns the locations with the IDs in the data, in the order given
+func locationsOf(data *api.RouterLocationData, ids ...int) []api.Location {
+	locations := make([]api.Location, 0, len(ids))
+	for _, id := range ids {
+		for _, location := range data.Locations {
+			if location.ID == id {
+				locations = append(locations, location)
+			}
+		}
+	}
+
+	return locations
+}
+
+func Test_findPaths(t *testing.T) {
+	sample := sampleData()
+	diamond := diamondData()
+
+	tests := []struct {
+		name     string
+		data     *api.RouterLocationData
+		from, to string
+		all      bool
+		want     *pathResult
+		wantErr  string
+	}{
+		{
+			name: "finds the shortest paths between locations given by name and postcode",
+			data: sample,
+			from: "lancaster brewery",
+			to:   "LA111DX",
+			want: &pathResult{
+				From:          sample.Locations[3],
+				To:            sample.Locations[6],
+				RouterPaths:   [][]routerHop{{hopOf(sample, 14), hopOf(sample, 9), hopOf(sample, 15)}},
+				LocationPaths: [][]api.Location{locationsOf(sample, 4, 8, 7)},
+			},
+		},
+		{
+			name: "has no paths between locations which aren't connected",
+			data: sample,
+			from: "1",
+			to:   "4",
+			want: &pathResult{
+				From:          sample.Locations[0],
+				To:            sample.Locations[3],
+				RouterPaths:   [][]routerHop{},
+				LocationPaths: [][]api.Location{},
+			},
+		},
+		{
+			name: "keeps the first of equal-cost paths",
+			data: diamond,
+			from: "one",
+			to:   "four",
+			want: &pathResult{
+				From:          diamond.Locations[0],
+				To:            diamond.Locations[3],
+				RouterPaths:   [][]routerHop{{hopOf(diamond, 1), hopOf(diamond, 2), hopOf(diamond, 4)}},
+				LocationPaths: [][]api.Location{locationsOf(diamond, 1, 2, 4)},
+			},
+		},
+		{
+			name: "lists every equal-cost path with all",
+			data: diamond,
+			from: "one",
+			to:   "four",
+			all:  true,
+			want: &pathResult{
+				From: diamond.Locations[0],
+				To:   diamond.Locations[3],
+				RouterPaths: [][]routerHop{
+					{hopOf(diamond, 1), hopOf(diamond, 2), hopOf(diamond, 4)},
+					{hopOf(diamond, 1), hopOf(diamond, 3), hopOf(diamond, 4)},
+				},
+				LocationPaths: [][]api.Location{
+					locationsOf(diamond, 1, 2, 4),
+					locationsOf(diamond, 1, 3, 4),
+				},
+			},
+		},
+		{
+			name:    "fails on an unknown from location",
+			data:    sample,
+			from:    "Blackpool Tower",
+			to:      "7",
+			wantErr: `no location matches "Blackpool Tower"`,
+		},
+		{
+			name:    "fails on an unknown to location",
+			data:    sample,
+			from:    "4",
+			to:      "99",
+			wantErr: `no location matches "99"`,
+		},
+		{
+			name:    "fails on a query matching more than one location",
+			data:    sample,
+			from:    "BE12 2ND",
+			to:      "7",
+			wantErr: `"BE12 2ND" matches 2 locations: 1 (Birmingham Motorcycle Museum), 2 (Birmingham Hippodrome)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findPaths(newGraph(t, tt.data), tt.from, tt.to, tt.all)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
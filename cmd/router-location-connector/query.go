@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog"
+
+	"router-location-connecter/api"
+	"router-location-connecter/graph"
+	"router-location-connecter/output"
+)
+
+// locationResult is what query prints for a location
+type locationResult struct {
+	Location api.Location  `json:"location"`
+	Routers  []api.Router  `json:"routers"`
+	Links    []output.Link `json:"links"`
+}
+
+// routerResult is what query prints for a router
+type routerResult struct {
+	Router     api.Router   `json:"router"`
+	Location   api.Location `json:"location"`
+	Neighbours []api.Router `json:"neighbours"`
+}
+
+// queryCommand looks up a single router or location by ID in the router graph, printing it as JSON with what it's
+// linked to. The data is streamed straight into the graph so storage is never touched
+func queryCommand(ctx context.Context, log zerolog.Logger, args []string) int {
+	var (
+		source               sourceFlags
+		graphOpts            graphFlags
+		locationID, routerID int
+	)
+
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	setUsage(fs, "[flags]", "prints a router (-router=ID) or location (-location=ID) and what it's linked to as JSON")
+	source.register(fs)
+	graphOpts.register(fs)
+	fs.IntVar(&locationID, "location", 0, "ID of the location to look up")
+	fs.IntVar(&routerID, "router", 0, "ID of the router to look up")
+	_ = fs.Parse(args)
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	if set["location"] == set["router"] {
+		fmt.Fprintln(os.Stderr, "query needs exactly one of -location or -router")
+		return 2
+	}
+
+	policy, err := graphOpts.policy()
+	if err != nil {
+		log.Error().Err(err).Msg("invalid link policy")
+		return 2
+	}
+
+	builder := graph.NewBuilder(graph.WithLinkPolicy(policy))
+	if err := source.client().StreamRouterLocationData(ctx, builder); err != nil {
+		log.Error().Err(err).Msg("get router location data")
+		return 2
+	}
+
+	routerGraph, err := builder.Build()
+	if err != nil {
+		log.Error().Err(err).Msg("build router graph")
+		return 2
+	}
+
+	var result any
+	if set["location"] {
+		result, err = queryLocation(routerGraph, locationID)
+	} else {
+		result, err = queryRouter(routerGraph, routerID)
+	}
+
+	if err != nil {
+		log.Error().Err(err).Msg("query router graph")
+		return 2
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(result); err != nil {
+		log.Error().Err(err).Msg("write query result")
+		return 2
+	}
+
+	return 0
+}
+
+func queryLocation(routerGraph *graph.Graph, id int) (*locationResult, error) {
+	location, ok := routerGraph.Location(id)
+	if !ok {
+		return nil, fmt.Errorf("location %d not found", id)
+	}
+
+	result := &locationResult{
+		Location: location,
+		Routers:  routerGraph.RoutersAt(id),
+		Links:    make([]output.Link, 0),
+	}
+
+	for _, link := range routerGraph.LinksOf(id) {
+		dest, _ := routerGraph.Location(link.DestinationID)
+
+		pairs := make([]output.RouterPair, 0, len(link.Routers))
+		for _, pair := range link.Routers {
+			src, _ := routerGraph.Router(pair.A)
+			linked, _ := routerGraph.Router(pair.B)
+
+			pairs = append(pairs, output.RouterPair{
+				SourceID:        src.ID,
+				SourceName:      src.Name,
+				DestinationID:   linked.ID,
+				DestinationName: linked.Name,
+			})
+		}
+
+		result.Links = append(result.Links, output.Link{Source: location, Destination: dest, Routers: pairs})
+	}
+
+	return result, nil
+}
+
+func queryRouter(routerGraph *graph.Graph, id int) (*routerResult, error) {
+	router, ok := routerGraph.Router(id)
+	if !ok {
+		return nil, fmt.Errorf("router %d not found", id)
+	}
+
+	location, _ := routerGraph.Location(router.LocationID)
+
+	result := &routerResult{
+		Router:     router,
+		Location:   location,
+		Neighbours: make([]api.Router, 0),
+	}
+
+	for _, neighbour := range routerGraph.Neighbours(id) {
+		linked, _ := routerGraph.Router(neighbour)
+		result.Neighbours = append(result.Neighbours, linked)
+	}
+
+	return result, nil
+}
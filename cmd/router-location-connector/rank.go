@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog"
+
+	"router-location-connecter/api"
+	"router-location-connecter/graph"
+)
+
+// rankEntry is a location, or with routers a router, ranked by its centrality
+type rankEntry struct {
+	Rank       int              `json:"rank"`
+	Location   *api.Location    `json:"location,omitempty"`
+	Router     *routerHop       `json:"router,omitempty"`
+	Centrality graph.Centrality `json:"centrality"`
+}
+
+// rankCommand lists the most central locations, or routers, by degree, betweenness or closeness centrality
+func rankCommand(ctx context.Context, log zerolog.Logger, args []string) int {
+	var (
+		data    graphSource
+		by      string
+		top     int
+		routers bool
+		samples int
+		format  string
+	)
+
+	fs := flag.NewFlagSet("rank", flag.ExitOnError)
+	setUsage(fs, "[flags]", "ranks locations, or routers, by degree, betweenness or closeness centrality")
+	data.register(fs)
+	fs.StringVar(&by, "by", string(graph.Betweenness), "centrality metric ranked by, one of degree|betweenness|closeness")
+	fs.IntVar(&top, "top", 10, "number of most central locations or routers listed, 0 lists every one")
+	fs.BoolVar(&routers, "routers", false, "rank routers over the router links rather than locations over the location links")
+	fs.IntVar(&samples, "samples", 0, "estimate betweenness and closeness by searching from only this many locations or routers, 0 searches from every one")
+	fs.StringVar(&format, "format", _formatText, "format the ranking is written in, one of text|json")
+	_ = fs.Parse(args)
+
+	metric, err := graph.ParseMetric(by)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid centrality metric")
+		return 2
+	}
+
+	if top < 0 {
+		log.Error().Msgf("invalid top %d, must be 0 or more", top)
+		return 2
+	}
+
+	if samples < 0 {
+		log.Error().Msgf("invalid samples %d, must be 0 or more", samples)
+		return 2
+	}
+
+	if _, err := parseFormat(format); err != nil {
+		log.Error().Err(err).Msg("invalid rank format")
+		return 2
+	}
+
+	routerGraph, err := data.build(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("build router graph")
+		return 2
+	}
+
+	entries := rank(routerGraph, metric, top, routers, samples)
+
+	err = writeResult(os.Stdout, format, entries, func(w io.Writer) error {
+		return writeRankText(w, entries)
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("write ranking")
+		return 2
+	}
+
+	return 0
+}
+
+// rank ranks the locations, or routers, by the metric keeping the top most central. Centrality is estimated from
+// searches from samples of them when samples is more than 0
+func rank(routerGraph *graph.Graph, metric graph.Metric, top int, routers bool, samples int) []rankEntry {
+	centralities := routerGraph.LocationCentrality(graph.WithSamples(samples))
+	if routers {
+		centralities = routerGraph.RouterCentrality(graph.WithSamples(samples))
+	}
+
+	ranked := graph.Rank(centralities, metric)
+	if top > 0 && top < len(ranked) {
+		ranked = ranked[:top]
+	}
+
+	entries := make([]rankEntry, 0, len(ranked))
+	for i, centrality := range ranked {
+		entry := rankEntry{Rank: i + 1, Centrality: centrality}
+
+		if routers {
+			hop := lookupRouter(routerGraph, centrality.ID)
+			entry.Router = &hop
+		} else {
+			location, _ := routerGraph.Location(centrality.ID)
+			entry.Location = &location
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func writeRankText(w io.Writer, entries []rankEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "rank\tname\tdegree\tbetweenness\tcloseness")
+
+	for _, entry := range entries {
+		var name string
+		if entry.Router != nil {
+			name = routerText(*entry.Router)
+		} else {
+			name = fmt.Sprintf("[%s] (%s)", entry.Location.Name, entry.Location.Postcode)
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%.3f\t%.3f\n",
+			entry.Rank, name, entry.Centrality.Degree, entry.Centrality.Betweenness, entry.Centrality.Closeness)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/graph"
+)
+
+func Test_rank(t *testing.T) {
+	sample := sampleData()
+
+	tests := []struct {
+		name    string
+		metric  graph.Metric
+		top     int
+		routers bool
+		samples int
+		wantIDs []int
+	}{
+		{
+			name:    "ranks every location with ties ordered by ID",
+			metric:  graph.Betweenness,
+			wantIDs: []int{4, 8, 5, 7, 2, 6, 1, 3},
+		},
+		{
+			name:    "keeps the top locations",
+			metric:  graph.Closeness,
+			top:     3,
+			wantIDs: []int{4, 8, 5},
+		},
+		{
+			name:    "keeps every location when top is more than there are",
+			metric:  graph.Degree,
+			top:     20,
+			wantIDs: []int{4, 8, 5, 7, 2, 6, 1, 3},
+		},
+		{
+			name:    "ranks routers with ties broken by the other metrics then by ID",
+			metric:  graph.Degree,
+			top:     9,
+			routers: true,
+			wantIDs: []int{14, 9, 15, 5, 4, 10, 3, 6, 7},
+		},
+		{
+			name:    "ranks routers by betweenness",
+			metric:  graph.Betweenness,
+			top:     4,
+			routers: true,
+			wantIDs: []int{14, 9, 15, 5},
+		},
+		{
+			name:    "ranks every location exactly when sampling at least as many as there are",
+			metric:  graph.Betweenness,
+			samples: 8,
+			wantIDs: []int{4, 8, 5, 7, 2, 6, 1, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routerGraph := newGraph(t, sample)
+
+			entries := rank(routerGraph, tt.metric, tt.top, tt.routers, tt.samples)
+
+			ids := make([]int, 0, len(entries))
+			for i, entry := range entries {
+				ids = append(ids, entry.Centrality.ID)
+				assert.Equal(t, i+1, entry.Rank)
+
+				// an entry describes a router or a location, never both
+				if tt.routers {
+					assert.Nil(t, entry.Location)
+					assert.Equal(t, hopOf(sample, entry.Centrality.ID), *entry.Router)
+				} else {
+					assert.Nil(t, entry.Router)
+					assert.Equal(t, locationsOf(sample, entry.Centrality.ID)[0], *entry.Location)
+				}
+			}
+
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func Test_rank_Centrality(t *testing.T) {
+	routerGraph := newGraph(t, sampleData())
+
+	centralities := make(map[int]graph.Centrality)
+	for _, centrality := range routerGraph.RouterCentrality() {
+		centralities[centrality.ID] = centrality
+	}
+
+	// entries carry every metric whichever they're ranked by
+	for _, entry := range rank(routerGraph, graph.Closeness, 0, true, 0) {
+		assert.Equal(t, centralities[entry.Centrality.ID], entry.Centrality)
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"router-location-connecter/app"
+	"router-location-connecter/diff"
+	"router-location-connecter/output"
+	"router-location-connecter/validate"
+)
+
+// runCommand stores router location data and outputs the links between locations, the original behaviour of the CLI
+func runCommand(ctx context.Context, log zerolog.Logger, args []string) int {
+	var (
+		source         sourceFlags
+		graphOpts      graphFlags
+		out            outputFlags
+		ingest         ingestFlags
+		store          storageFlags
+		persistData    bool
+		validateMode   string
+		validateFormat string
+		watch          time.Duration
+		webhook        string
+		noSnapshot     bool
+		snapshotKeep   int
+		snapshotMaxAge time.Duration
+	)
+
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	setUsage(fs, "[flags]", "stores router location data and outputs the links between locations, exiting 1 when -validate=fail finds\n"+
+		"data-quality errors")
+	source.register(fs)
+	graphOpts.register(fs)
+	out.register(fs)
+	ingest.register(fs)
+	fs.BoolVar(&persistData, "persist-data", false, "keep router location data between runs")
+	store.register(fs, "storage backend to use, one of memory|redis")
+	fs.StringVar(&validateMode, "validate", string(validate.Warn), "check router location data quality, one of warn|fail|off, fail exits non-zero when errors are found")
+	fs.StringVar(&validateFormat, "validate-format", validate.FormatText, "format the validation report is written to stderr in, one of text|json")
+	fs.DurationVar(&watch, "watch", 0, "re-poll router location data on this interval writing change events rather than location links, 0 runs once")
+	fs.StringVar(&webhook, "webhook", "", "url change events are posted to in watch mode, stdout when empty")
+	fs.BoolVar(&noSnapshot, "no-snapshot", false, "don't store an immutable snapshot of the router location data after every ingest")
+	fs.IntVar(&snapshotKeep, "snapshot-keep", 10, "how many of the newest snapshots are kept, 0 keeps them all")
+	fs.DurationVar(&snapshotMaxAge, "snapshot-max-age", 0, "delete snapshots older than this, 0 keeps them forever")
+	_ = fs.Parse(args)
+
+	mode, err := validate.ParseMode(validateMode)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid validate mode")
+		return 2
+	}
+
+	reportFormat, err := validate.ParseFormat(validateFormat)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid validate format")
+		return 2
+	}
+
+	ingestOpt, err := ingest.option()
+	if err != nil {
+		log.Error().Err(err).Msg("invalid ingest flags")
+		return 2
+	}
+
+	appOpts := []app.Option{ingestOpt, app.WithValidation(mode, os.Stderr, reportFormat)}
+	if !noSnapshot {
+		appOpts = append(appOpts, app.WithSnapshots(snapshotKeep, snapshotMaxAge))
+	}
+
+	return process(ctx, log, processConfig{
+		source:      source,
+		graph:       graphOpts,
+		output:      out,
+		storage:     store,
+		persistData: persistData,
+		appOpts:     appOpts,
+		watch:       watch,
+		webhook:     webhook,
+	})
+}
+
+// exportCommand outputs the links between locations without touching Redis, keeping the data in memory for the run
+func exportCommand(ctx context.Context, log zerolog.Logger, args []string) int {
+	var (
+		source    sourceFlags
+		graphOpts graphFlags
+		out       outputFlags
+		ingest    ingestFlags
+	)
+
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	setUsage(fs, "[flags]", "outputs the links between locations using in-memory storage")
+	source.register(fs)
+	graphOpts.register(fs)
+	out.register(fs)
+	ingest.register(fs)
+	_ = fs.Parse(args)
+
+	ingestOpt, err := ingest.option()
+	if err != nil {
+		log.Error().Err(err).Msg("invalid ingest flags")
+		return 2
+	}
+
+	return process(ctx, log, processConfig{
+		source:  source,
+		graph:   graphOpts,
+		output:  out,
+		storage: storageFlags{storageType: _storageMemory},
+		appOpts: []app.Option{ingestOpt},
+	})
+}
+
+// processExitCode is 1 when -validate=fail stopped the run on data-quality errors, like the validate command, and 2 for
+// any other failure
+func processExitCode(err error) int {
+	var validationErr *validate.Error
+	if errors.As(err, &validationErr) {
+		return 1
+	}
+
+	return 2
+}
+
+// processConfig is what's needed to run app.Process from the flags of a command
+type processConfig struct {
+	source      sourceFlags
+	graph       graphFlags
+	output      outputFlags
+	storage     storageFlags
+	persistData bool
+	appOpts     []app.Option
+	// watch is the interval router location data is re-polled on, zero runs once
+	watch   time.Duration
+	webhook string
+}
+
+// process runs the app once returning the exit code, storage is cleared afterwards unless the data is persisted
+func process(ctx context.Context, log zerolog.Logger, cfg processConfig) int {
+	policy, err := cfg.graph.policy()
+	if err != nil {
+		log.Error().Err(err).Msg("invalid link policy")
+		return 2
+	}
+
+	var (
+		emitter     output.Emitter
+		closeOutput func() error
+	)
+
+	// in watch mode changes are written as events so the location links output isn't needed
+	if cfg.watch > 0 {
+		emitter, closeOutput = output.NewText(io.Discard), func() error { return nil }
+	} else if emitter, closeOutput, err = cfg.output.emitter(); err != nil {
+		log.Error().Err(err).Msg("invalid output")
+		return 2
+	}
+
+	storageClient, err := cfg.storage.open(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg(_errStorage)
+
+		if err := closeOutput(); err != nil {
+			log.Error().Err(err).Msg("error closing output")
+		}
+
+		return 2
+	}
+
+	runner := app.NewApp(cfg.source.client(), storageClient, emitter, log,
+		append([]app.Option{app.WithLinkPolicy(policy)}, cfg.appOpts...)...)
+
+	exitCode := 0
+	if cfg.watch > 0 {
+		watchCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		var sink diff.Sink = diff.NewWriterSink(os.Stdout)
+		if cfg.webhook != "" {
+			sink = diff.NewWebhookSink(cfg.webhook, time.Duration(cfg.source.timeout)*time.Second)
+		}
+
+		if err := runner.Watch(watchCtx, cfg.watch, sink); err != nil {
+			log.Error().Err(err).Msg("watch router location data")
+
+			exitCode = 2
+		}
+	} else if err := runner.Process(ctx); err != nil {
+		log.Error().Err(err).Msg("process router location data")
+
+		exitCode = processExitCode(err)
+	}
+
+	// Close the storage client after finishing, snapshots are kept either way
+	if !cfg.persistData {
+		if err := storageClient.Clear(ctx); err != nil {
+			log.Error().Err(err).Msg("error clearing storage")
+		}
+	}
+
+	if err := storageClient.Close(); err != nil {
+		log.Error().Err(err).Msg("error closing storage")
+	}
+
+	if err := closeOutput(); err != nil {
+		log.Error().Err(err).Msg("error closing output")
+	}
+
+	return exitCode
+}
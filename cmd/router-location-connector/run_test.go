@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/validate"
+)
+
+func Test_processExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "exits 1 when validation stopped the run",
+			err:  fmt.Errorf("validate router location data: %w", &validate.Error{Report: &validate.Report{}}),
+			want: 1,
+		},
+		{
+			name: "exits 2 on any other failure",
+			err:  errors.New("get router location data: connection refused"),
+			want: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, processExitCode(tt.err))
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"router-location-connecter/app"
+	"router-location-connecter/output"
+	"router-location-connecter/server"
+	"router-location-connecter/storage"
+)
+
+// serveCommand serves location links over a REST API, refreshing them from the upstream API on an interval until
+// interrupted
+func serveCommand(ctx context.Context, log zerolog.Logger, args []string) int {
+	var (
+		source          sourceFlags
+		graphOpts       graphFlags
+		ingest          ingestFlags
+		store           storageFlags
+		persistData     bool
+		address         string
+		refreshInterval time.Duration
+	)
+
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	setUsage(fs, "[flags]", "serves location links over a REST API, refreshing them from the upstream API on an interval")
+	source.register(fs)
+	graphOpts.register(fs)
+	ingest.register(fs)
+	fs.BoolVar(&persistData, "persist-data", false, "keep router location data after shutting down")
+	store.register(fs, "storage backend to use, one of memory|redis")
+	fs.StringVar(&address, "addr", ":8080", "address to listen on")
+	fs.DurationVar(&refreshInterval, "refresh", 5*time.Minute, "how often router location data is refreshed from the upstream API")
+	_ = fs.Parse(args)
+
+	if refreshInterval <= 0 {
+		log.Error().Msgf("invalid refresh %s, must be more than 0", refreshInterval)
+		fs.Usage()
+
+		return 2
+	}
+
+	policy, err := graphOpts.policy()
+	if err != nil {
+		log.Error().Err(err).Msg("invalid link policy")
+		return 2
+	}
+
+	ingestOpt, err := ingest.option()
+	if err != nil {
+		log.Error().Err(err).Msg("invalid ingest flags")
+		return 2
+	}
+
+	storageClient, err := store.open(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg(_errStorage)
+		return 2
+	}
+
+	// every storage backend stages, requests being served from the active data set while a refresh is staged
+	stager, ok := storageClient.(storage.Stager)
+	if !ok {
+		log.Error().Msgf("storage %q can't be served as it can't stage refreshes", store.storageType)
+
+		if err := storageClient.Close(); err != nil {
+			log.Error().Err(err).Msg("error closing storage")
+		}
+
+		return 2
+	}
+
+	// links are read back from storage by the server so the emitted output isn't needed
+	runner := app.NewApp(source.client(), storageClient, output.NewText(io.Discard), log, app.WithLinkPolicy(policy), ingestOpt)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := server.New(&runner, stager,
+		server.WithAddress(address),
+		server.WithRefreshInterval(refreshInterval))
+
+	exitCode := 0
+	if err := srv.Run(ctx); err != nil {
+		log.Error().Err(err).Msg("serve location links")
+
+		exitCode = 2
+	}
+
+	if !persistData {
+		if err := storageClient.Clear(context.Background()); err != nil {
+			log.Error().Err(err).Msg("error clearing storage")
+		}
+	}
+
+	if err := storageClient.Close(); err != nil {
+		log.Error().Err(err).Msg("error closing storage")
+	}
+
+	return exitCode
+}
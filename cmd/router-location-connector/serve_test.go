@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_serveCommand_refresh(t *testing.T) {
+	tests := []struct {
+		name    string
+		refresh string
+	}{
+		{name: "exits 2 on a zero refresh", refresh: "-refresh=0"},
+		{name: "exits 2 on a negative refresh", refresh: "-refresh=-1m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, 2, serveCommand(context.Background(), zerolog.Nop(), []string{tt.refresh}))
+		})
+	}
+}
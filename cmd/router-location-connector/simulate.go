@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"router-location-connecter/api"
+	"router-location-connecter/diff"
+	"router-location-connecter/graph"
+)
+
+// simulateResult is what simulate prints, the location links are those left after the removal
+type simulateResult struct {
+	Removed    removedDetail     `json:"removed"`
+	Links      []diff.Link       `json:"links"`
+	LinksLost  []diff.Link       `json:"links_lost"`
+	Partitions []partitionDetail `json:"partitions"`
+}
+
+// removedDetail is the removal with its routers and locations looked up
+type removedDetail struct {
+	Routers     []routerHop    `json:"routers"`
+	RouterLinks []routerLink   `json:"router_links"`
+	Locations   []api.Location `json:"locations"`
+}
+
+type routerLink struct {
+	A routerHop `json:"a"`
+	B routerHop `json:"b"`
+}
+
+// partitionDetail is a component split by the removal, the parts being looked up after the removal
+type partitionDetail struct {
+	Component componentDetail   `json:"component"`
+	Parts     []componentDetail `json:"parts"`
+}
+
+// simulateCommand removes routers, router links and locations from the router graph and prints the location links
+// left, the location links lost and the components split apart. Like diff it exits 0 when nothing is lost, 1 when
+// location links are lost or components split and 2 on failure
+func simulateCommand(ctx context.Context, log zerolog.Logger, args []string) int {
+	var (
+		data      graphSource
+		routers   listFlag
+		links     listFlag
+		locations listFlag
+		format    string
+	)
+
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	setUsage(fs, "[flags]", "removes routers, router links or locations and prints the location links lost and components split apart,\n"+
+		"exiting 1 when anything is lost")
+	data.register(fs)
+	fs.Var(&routers, "router", "ID of a router to remove, may be given more than once")
+	fs.Var(&links, "link", "router IDs of a router link to remove, as A-B, may be given more than once")
+	fs.Var(&locations, "location", "ID, name or postcode of a location to remove with every router at it, may be given more than once")
+	fs.StringVar(&format, "format", _formatText, "format the simulation is written in, one of text|json")
+	_ = fs.Parse(args)
+
+	if len(routers) == 0 && len(links) == 0 && len(locations) == 0 {
+		log.Error().Msg("nothing to remove, give at least one router, link or location")
+		return 2
+	}
+
+	if _, err := parseFormat(format); err != nil {
+		log.Error().Err(err).Msg("invalid simulate format")
+		return 2
+	}
+
+	routerGraph, err := data.build(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("build router graph")
+		return 2
+	}
+
+	removal, err := parseRemoval(routerGraph, routers, links, locations)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid removal")
+		return 2
+	}
+
+	result, err := simulate(routerGraph, removal)
+	if err != nil {
+		log.Error().Err(err).Msg("simulate removal")
+		return 2
+	}
+
+	err = writeResult(os.Stdout, format, result, func(w io.Writer) error {
+		return writeSimulationText(w, result)
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("write simulation")
+		return 2
+	}
+
+	if len(result.LinksLost) > 0 || len(result.Partitions) > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// parseRemoval parses the routers, router links and locations to remove, locations being looked up as path does
+func parseRemoval(routerGraph *graph.Graph, routers, links, locations []string) (graph.Removal, error) {
+	var removal graph.Removal
+
+	for _, router := range routers {
+		id, err := strconv.Atoi(router)
+		if err != nil {
+			return graph.Removal{}, fmt.Errorf("invalid router ID %q", router)
+		}
+
+		removal.Routers = append(removal.Routers, id)
+	}
+
+	for _, link := range links {
+		a, b, err := parseRouterLink(link)
+		if err != nil {
+			return graph.Removal{}, err
+		}
+
+		removal.RouterLinks = append(removal.RouterLinks, graph.RouterPair{A: a, B: b})
+	}
+
+	for _, query := range locations {
+		location, err := routerGraph.FindLocation(query)
+		if err != nil {
+			return graph.Removal{}, err
+		}
+
+		removal.Locations = append(removal.Locations, location.ID)
+	}
+
+	return removal, nil
+}
+
+// simulate takes the removal out of the router graph and compares what's left with it
+func simulate(routerGraph *graph.Graph, removal graph.Removal) (*simulateResult, error) {
+	after, err := routerGraph.Without(removal)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &simulateResult{
+		Removed: removedDetail{
+			Routers:     make([]routerHop, 0, len(removal.Routers)),
+			RouterLinks: make([]routerLink, 0, len(removal.RouterLinks)),
+			Locations:   make([]api.Location, 0, len(removal.Locations)),
+		},
+		Links:      make([]diff.Link, 0),
+		LinksLost:  make([]diff.Link, 0),
+		Partitions: make([]partitionDetail, 0),
+	}
+
+	for _, id := range removal.Routers {
+		result.Removed.Routers = append(result.Removed.Routers, lookupRouter(routerGraph, id))
+	}
+
+	for _, pair := range removal.RouterLinks {
+		result.Removed.RouterLinks = append(result.Removed.RouterLinks, routerLink{
+			A: lookupRouter(routerGraph, pair.A),
+			B: lookupRouter(routerGraph, pair.B),
+		})
+	}
+
+	for _, id := range removal.Locations {
+		location, _ := routerGraph.Location(id)
+		result.Removed.Locations = append(result.Removed.Locations, location)
+	}
+
+	baseline, remaining := diff.FromGraph(routerGraph), diff.FromGraph(after)
+
+	for _, pair := range remaining.Links {
+		source, _ := after.Location(pair.SourceID)
+		destination, _ := after.Location(pair.DestinationID)
+		result.Links = append(result.Links, diff.Link{Source: source, Destination: destination})
+	}
+
+	result.LinksLost = append(result.LinksLost, diff.Compare(baseline, remaining).LinksRemoved...)
+
+	for _, partition := range routerGraph.Partitions(after) {
+		detail := partitionDetail{
+			Component: lookupComponent(routerGraph, partition.Component),
+			Parts:     make([]componentDetail, 0, len(partition.Parts)),
+		}
+
+		for _, part := range partition.Parts {
+			detail.Parts = append(detail.Parts, lookupComponent(after, part))
+		}
+
+		result.Partitions = append(result.Partitions, detail)
+	}
+
+	return result, nil
+}
+
+func writeSimulationText(w io.Writer, result *simulateResult) error {
+	var b strings.Builder
+
+	b.WriteString("removed\n")
+
+	for _, hop := range result.Removed.Routers {
+		fmt.Fprintf(&b, "  router %s\n", routerText(hop))
+	}
+
+	for _, link := range result.Removed.RouterLinks {
+		fmt.Fprintf(&b, "  router link %s - %s\n", routerText(link.A), routerText(link.B))
+	}
+
+	for _, location := range result.Removed.Locations {
+		fmt.Fprintf(&b, "  location [%s] (%s)\n", location.Name, location.Postcode)
+	}
+
+	fmt.Fprintf(&b, "\n%s left\n", plural(len(result.Links), "location link"))
+	for _, link := range result.Links {
+		fmt.Fprintf(&b, "  [%s] <-> [%s]\n", link.Source.Name, link.Destination.Name)
+	}
+
+	fmt.Fprintf(&b, "\n%s lost\n", plural(len(result.LinksLost), "location link"))
+	for _, link := range result.LinksLost {
+		fmt.Fprintf(&b, "  [%s] <-> [%s]\n", link.Source.Name, link.Destination.Name)
+	}
+
+	fmt.Fprintf(&b, "\n%s split\n", plural(len(result.Partitions), "component"))
+	for _, partition := range result.Partitions {
+		fmt.Fprintf(&b, "  %s into %s\n", locationNames(partition.Component.Locations), partsText(partition.Parts))
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func partsText(parts []componentDetail) string {
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		names = append(names, locationNames(part.Locations))
+	}
+
+	return strings.Join(names, " | ")
+}
+
+func locationNames(locations []api.Location) string {
+	names := make([]string, 0, len(locations))
+	for _, location := range locations {
+		names = append(names, fmt.Sprintf("[%s]", location.Name))
+	}
+
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+	"router-location-connecter/diff"
+	"router-location-connecter/graph"
+)
+
+func Test_parseRemoval(t *testing.T) {
+	tests := []struct {
+		name      string
+		routers   []string
+		links     []string
+		locations []string
+		want      graph.Removal
+		wantErr   string
+	}{
+		{
+			name:      "parses routers, router links and locations looked up by ID, name or postcode",
+			routers:   []string{"9", "14"},
+			links:     []string{"9-15"},
+			locations: []string{"3", "lancaster castle", "LE132SW"},
+			want: graph.Removal{
+				Routers:     []int{9, 14},
+				RouterLinks: []graph.RouterPair{{A: 9, B: 15}},
+				Locations:   []int{3, 7, 8},
+			},
+		},
+		{
+			name: "is empty when nothing is given",
+			want: graph.Removal{},
+		},
+		{
+			name:    "fails on a router ID which isn't a number",
+			routers: []string{"cdn10"},
+			wantErr: `invalid router ID "cdn10"`,
+		},
+		{
+			name:    "fails on a malformed link",
+			links:   []string{"a-b"},
+			wantErr: `invalid router link "a-b", must be router IDs as A-B`,
+		},
+		{
+			name:    "fails on a link without a separator",
+			links:   []string{"915"},
+			wantErr: `invalid router link "915", must be router IDs as A-B`,
+		},
+		{
+			name:      "fails on an unknown location",
+			locations: []string{"99"},
+			wantErr:   `no location matches "99"`,
+		},
+		{
+			name:      "fails on a location query matching more than one location",
+			locations: []string{"BE12 2ND"},
+			wantErr:   `"BE12 2ND" matches 2 locations: 1 (Birmingham Motorcycle Museum), 2 (Birmingham Hippodrome)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRemoval(newGraph(t, sampleData()), tt.routers, tt.links, tt.locations)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_simulate(t *testing.T) {
+	sample := sampleData()
+
+	link := func(source, destination int) diff.Link {
+		locations := locationsOf(sample, source, destination)
+		return diff.Link{Source: locations[0], Destination: locations[1]}
+	}
+
+	// the routers left after removing router 9 lose their links to it
+	without9 := sampleData()
+	without9.Routers[11].RouterLinks = []int{4, 10}
+	without9.Routers[12].RouterLinks = []int{3}
+
+	allLinks := []diff.Link{link(2, 6), link(4, 5), link(4, 8), link(7, 8)}
+
+	allRouters := make([]int, 0, len(sample.Routers))
+	allRouterHops := make([]routerHop, 0, len(sample.Routers))
+	for _, router := range sample.Routers {
+		allRouters = append(allRouters, router.ID)
+		allRouterHops = append(allRouterHops, hopOf(sample, router.ID))
+	}
+
+	tests := []struct {
+		name    string
+		removal graph.Removal
+		want    *simulateResult
+		wantErr string
+	}{
+		{
+			name:    "removing a router loses the location links through it and splits its component",
+			removal: graph.Removal{Routers: []int{9}},
+			want: &simulateResult{
+				Removed: removedDetail{
+					Routers:     []routerHop{hopOf(sample, 9)},
+					RouterLinks: []routerLink{},
+					Locations:   []api.Location{},
+				},
+				Links:     []diff.Link{link(2, 6), link(4, 5)},
+				LinksLost: []diff.Link{link(4, 8), link(7, 8)},
+				Partitions: []partitionDetail{{
+					Component: componentDetail{
+						Locations: locationsOf(sample, 4, 5, 7, 8),
+						Routers: []routerHop{
+							hopOf(sample, 3), hopOf(sample, 4), hopOf(sample, 9),
+							hopOf(sample, 10), hopOf(sample, 14), hopOf(sample, 15),
+						},
+					},
+					Parts: []componentDetail{
+						{Locations: locationsOf(sample, 4, 5), Routers: []routerHop{hopOf(without9, 4), hopOf(without9, 10), hopOf(without9, 14)}},
+						{Locations: locationsOf(sample, 7), Routers: []routerHop{hopOf(without9, 3), hopOf(without9, 15)}},
+						{Locations: locationsOf(sample, 8), Routers: []routerHop{}},
+					},
+				}},
+			},
+		},
+		{
+			name:    "removing a router link between routers at the same location loses nothing",
+			removal: graph.Removal{RouterLinks: []graph.RouterPair{{A: 4, B: 14}}},
+			want: &simulateResult{
+				Removed: removedDetail{
+					Routers:     []routerHop{},
+					RouterLinks: []routerLink{{A: hopOf(sample, 4), B: hopOf(sample, 14)}},
+					Locations:   []api.Location{},
+				},
+				Links:      allLinks,
+				LinksLost:  []diff.Link{},
+				Partitions: []partitionDetail{},
+			},
+		},
+		{
+			name:    "removing every location loses every location link and leaves no components to split",
+			removal: graph.Removal{Locations: []int{1, 2, 3, 4, 5, 6, 7, 8}},
+			want: &simulateResult{
+				Removed: removedDetail{
+					Routers:     []routerHop{},
+					RouterLinks: []routerLink{},
+					Locations:   sample.Locations,
+				},
+				Links:      []diff.Link{},
+				LinksLost:  allLinks,
+				Partitions: []partitionDetail{},
+			},
+		},
+		{
+			name:    "fails on unknown routers and locations and routers which aren't linked",
+			removal: graph.Removal{Routers: []int{99}, RouterLinks: []graph.RouterPair{{A: 1, B: 2}}, Locations: []int{99}},
+			wantErr: "router 99 not found\nlocation 99 not found\nrouters 1 and 2 aren't linked",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := simulate(newGraph(t, sample), tt.removal)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("removing every router loses every location link and splits every component", func(t *testing.T) {
+		got, err := simulate(newGraph(t, sample), graph.Removal{Routers: allRouters})
+		assert.NoError(t, err)
+
+		assert.Equal(t, allRouterHops, got.Removed.Routers)
+		assert.Equal(t, []diff.Link{}, got.Links)
+		assert.Equal(t, allLinks, got.LinksLost)
+
+		split := make([][]api.Location, 0, len(got.Partitions))
+		for _, partition := range got.Partitions {
+			split = append(split, partition.Component.Locations)
+			assert.Len(t, partition.Parts, len(partition.Component.Locations))
+		}
+
+		assert.Equal(t, [][]api.Location{locationsOf(sample, 4, 5, 7, 8), locationsOf(sample, 2, 6)}, split)
+	})
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"router-location-connecter/storage"
+)
+
+// snapshotsCommand lists the snapshots in storage oldest first
+func snapshotsCommand(ctx context.Context, log zerolog.Logger, args []string) int {
+	var (
+		store  storageFlags
+		format string
+	)
+
+	fs := flag.NewFlagSet("snapshots", flag.ExitOnError)
+	setUsage(fs, "[flags]", "lists the snapshots of router location data in storage oldest first")
+	store.register(fs, "storage backend to use, one of memory|redis")
+	fs.StringVar(&format, "format", _formatText, "format snapshots are listed in, one of text|json")
+	_ = fs.Parse(args)
+
+	if _, err := parseFormat(format); err != nil {
+		log.Error().Err(err).Msg("invalid snapshots format")
+		return 2
+	}
+
+	storageClient, err := store.open(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg(_errStorage)
+		return 2
+	}
+
+	defer func() {
+		if err := storageClient.Close(); err != nil {
+			log.Error().Err(err).Msg("error closing storage")
+		}
+	}()
+
+	infos, err := storageClient.ListSnapshots()
+	if err != nil {
+		log.Error().Err(err).Msg("list snapshots")
+		return 2
+	}
+
+	err = writeResult(os.Stdout, format, infos, func(w io.Writer) error {
+		return writeSnapshotsText(w, infos)
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("write snapshots")
+		return 2
+	}
+
+	return 0
+}
+
+func writeSnapshotsText(w io.Writer, infos []storage.SnapshotInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(tw, "id\tcreated\trouters\tlocations"); err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n",
+			info.ID, info.CreatedAt.Format(time.RFC3339), info.Routers, info.Locations); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
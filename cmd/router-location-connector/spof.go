@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"router-location-connecter/api"
+	"router-location-connecter/graph"
+)
+
+// spofResult is what spof prints, each failure listing the groups of locations it would leave disconnected from each
+// other, largest first
+type spofResult struct {
+	Routers       []routerFailure             `json:"routers"`
+	RouterLinks   []routerLinkFailure         `json:"router_links"`
+	Locations     []graph.LocationFailure     `json:"locations"`
+	LocationLinks []graph.LocationLinkFailure `json:"location_links"`
+}
+
+// routerFailure is a router failing with the location it's at
+type routerFailure struct {
+	Router routerHop        `json:"router"`
+	Split  [][]api.Location `json:"split"`
+}
+
+// routerLinkFailure is a router link failing with the locations of its routers
+type routerLinkFailure struct {
+	A     routerHop        `json:"a"`
+	B     routerHop        `json:"b"`
+	Split [][]api.Location `json:"split"`
+}
+
+// spofCommand lists the routers, locations and links which are single points of failure, or with router or link what
+// a given router or router link failing would disconnect. It exits 1 when any failure would disconnect locations and 2
+// on failure
+func spofCommand(ctx context.Context, log zerolog.Logger, args []string) int {
+	var (
+		data   graphSource
+		router string
+		link   string
+		format string
+	)
+
+	fs := flag.NewFlagSet("spof", flag.ExitOnError)
+	setUsage(fs, "[flags]", "lists the routers, locations and links which are single points of failure, exiting 1 when any failure\n"+
+		"would disconnect locations")
+	data.register(fs)
+	fs.StringVar(&router, "router", "", "ID of a router to report what its failure would disconnect")
+	fs.StringVar(&link, "link", "", "router IDs of a router link, as A-B, to report what its failure would disconnect")
+	fs.StringVar(&format, "format", _formatText, "format the failures are written in, one of text|json")
+	_ = fs.Parse(args)
+
+	if _, err := parseFormat(format); err != nil {
+		log.Error().Err(err).Msg("invalid spof format")
+		return 2
+	}
+
+	routerGraph, err := data.build(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("build router graph")
+		return 2
+	}
+
+	all := router == "" && link == ""
+
+	var result *spofResult
+	if all {
+		result = findSinglePointsOfFailure(routerGraph)
+	} else {
+		result, err = findFailures(routerGraph, router, link)
+		if err != nil {
+			log.Error().Err(err).Msg("find failures")
+			return 2
+		}
+	}
+
+	err = writeResult(os.Stdout, format, result, func(w io.Writer) error {
+		return writeFailuresText(w, result, all)
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("write failures")
+		return 2
+	}
+
+	if result.disconnects() {
+		return 1
+	}
+
+	return 0
+}
+
+// findSinglePointsOfFailure looks up every articulation router and location and every bridge link
+func findSinglePointsOfFailure(routerGraph *graph.Graph) *spofResult {
+	return newSpofResult(routerGraph, routerGraph.Failures().SinglePoints())
+}
+
+// findFailures reports what the given router and router link failing would disconnect, either may be empty
+func findFailures(routerGraph *graph.Graph, router, link string) (*spofResult, error) {
+	var (
+		failures = routerGraph.Failures()
+		points   graph.SinglePoints
+	)
+
+	if router != "" {
+		id, err := strconv.Atoi(router)
+		if err != nil {
+			return nil, fmt.Errorf("invalid router ID %q", router)
+		}
+
+		failure, err := failures.Router(id)
+		if err != nil {
+			return nil, err
+		}
+
+		points.Routers = append(points.Routers, failure)
+	}
+
+	if link != "" {
+		a, b, err := parseRouterLink(link)
+		if err != nil {
+			return nil, err
+		}
+
+		failure, err := failures.RouterLink(a, b)
+		if err != nil {
+			return nil, err
+		}
+
+		points.RouterLinks = append(points.RouterLinks, failure)
+	}
+
+	return newSpofResult(routerGraph, points), nil
+}
+
+// newSpofResult adds the location of every router to the failures
+func newSpofResult(routerGraph *graph.Graph, points graph.SinglePoints) *spofResult {
+	result := &spofResult{
+		Routers:       make([]routerFailure, 0, len(points.Routers)),
+		RouterLinks:   make([]routerLinkFailure, 0, len(points.RouterLinks)),
+		Locations:     make([]graph.LocationFailure, 0, len(points.Locations)),
+		LocationLinks: make([]graph.LocationLinkFailure, 0, len(points.LocationLinks)),
+	}
+
+	for _, failure := range points.Routers {
+		result.Routers = append(result.Routers, routerFailure{
+			Router: lookupRouter(routerGraph, failure.Router.ID),
+			Split:  failure.Split,
+		})
+	}
+
+	for _, failure := range points.RouterLinks {
+		result.RouterLinks = append(result.RouterLinks, routerLinkFailure{
+			A:     lookupRouter(routerGraph, failure.A.ID),
+			B:     lookupRouter(routerGraph, failure.B.ID),
+			Split: failure.Split,
+		})
+	}
+
+	result.Locations = append(result.Locations, points.Locations...)
+	result.LocationLinks = append(result.LocationLinks, points.LocationLinks...)
+
+	return result
+}
+
+// disconnects reports whether any of the failures would leave locations disconnected
+func (r *spofResult) disconnects() bool {
+	for _, failure := range r.Routers {
+		if len(failure.Split) > 0 {
+			return true
+		}
+	}
+
+	for _, failure := range r.RouterLinks {
+		if len(failure.Split) > 0 {
+			return true
+		}
+	}
+
+	for _, failure := range r.Locations {
+		if len(failure.Split) > 0 {
+			return true
+		}
+	}
+
+	for _, failure := range r.LocationLinks {
+		if len(failure.Split) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseRouterLink parses a router link given as A-B
+func parseRouterLink(link string) (int, int, error) {
+	errInvalid := fmt.Errorf("invalid router link %q, must be router IDs as A-B", link)
+
+	first, second, ok := strings.Cut(link, "-")
+	if !ok {
+		return 0, 0, errInvalid
+	}
+
+	a, errA := strconv.Atoi(first)
+	b, errB := strconv.Atoi(second)
+	if err := errors.Join(errA, errB); err != nil {
+		return 0, 0, errInvalid
+	}
+
+	return a, b, nil
+}
+
+func writeFailuresText(w io.Writer, result *spofResult, all bool) error {
+	var b strings.Builder
+
+	section := func(title string, lines []string) {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+
+		b.WriteString(title + "\n")
+
+		if len(lines) == 0 {
+			b.WriteString("  none\n")
+		}
+
+		for _, line := range lines {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+
+	lines := make([]string, 0, len(result.Routers))
+	for _, failure := range result.Routers {
+		lines = append(lines, fmt.Sprintf("%s %s", routerText(failure.Router), splitText(failure.Split)))
+	}
+	section("routers", lines)
+
+	lines = make([]string, 0, len(result.RouterLinks))
+	for _, failure := range result.RouterLinks {
+		lines = append(lines, fmt.Sprintf("%s - %s %s", routerText(failure.A), routerText(failure.B), splitText(failure.Split)))
+	}
+	section("router links", lines)
+
+	// only routers and router links are asked about, so location failures are only looked for in the full report
+	if all {
+		lines = make([]string, 0, len(result.Locations))
+		for _, failure := range result.Locations {
+			lines = append(lines, fmt.Sprintf("[%s] %s", failure.Location.Name, splitText(failure.Split)))
+		}
+		section("locations", lines)
+
+		lines = make([]string, 0, len(result.LocationLinks))
+		for _, failure := range result.LocationLinks {
+			lines = append(lines, fmt.Sprintf("[%s] - [%s] %s", failure.Source.Name, failure.Destination.Name, splitText(failure.Split)))
+		}
+		section("location links", lines)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// splitText describes the groups of locations a failure leaves disconnected from each other
+func splitText(split [][]api.Location) string {
+	if len(split) == 0 {
+		return "disconnects no locations"
+	}
+
+	groups := make([]string, 0, len(split))
+	for _, group := range split {
+		names := make([]string, 0, len(group))
+		for _, location := range group {
+			names = append(names, fmt.Sprintf("[%s]", location.Name))
+		}
+
+		groups = append(groups, strings.Join(names, " "))
+	}
+
+	return "splits " + strings.Join(groups, " | ")
+}
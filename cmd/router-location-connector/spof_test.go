@@ -0,0 +1,147 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+	"router-location-connecter/graph"
+)
+
+func Test_findFailures(t *testing.T) {
+	sample := sampleData()
+
+	tests := []struct {
+		name            string
+		router, link    string
+		want            *spofResult
+		wantDisconnects bool
+		wantErr         string
+	}{
+		{
+			name:   "reports what a router and a router link failing disconnect",
+			router: "14",
+			link:   "9-15",
+			want: &spofResult{
+				Routers: []routerFailure{{
+					Router: hopOf(sample, 14),
+					Split:  [][]api.Location{locationsOf(sample, 7, 8), locationsOf(sample, 4), locationsOf(sample, 5)},
+				}},
+				RouterLinks: []routerLinkFailure{{
+					A:     hopOf(sample, 9),
+					B:     hopOf(sample, 15),
+					Split: [][]api.Location{locationsOf(sample, 4, 5, 8), locationsOf(sample, 7)},
+				}},
+				Locations:     []graph.LocationFailure{},
+				LocationLinks: []graph.LocationLinkFailure{},
+			},
+			wantDisconnects: true,
+		},
+		{
+			name:   "has an empty split for a router whose location stays connected",
+			router: "3",
+			want: &spofResult{
+				Routers:       []routerFailure{{Router: hopOf(sample, 3), Split: [][]api.Location{}}},
+				RouterLinks:   []routerLinkFailure{},
+				Locations:     []graph.LocationFailure{},
+				LocationLinks: []graph.LocationLinkFailure{},
+			},
+		},
+		{
+			name: "reports nothing when nothing is asked about",
+			want: &spofResult{
+				Routers:       []routerFailure{},
+				RouterLinks:   []routerLinkFailure{},
+				Locations:     []graph.LocationFailure{},
+				LocationLinks: []graph.LocationLinkFailure{},
+			},
+		},
+		{
+			name:    "fails on a router ID which isn't a number",
+			router:  "cdn10",
+			wantErr: `invalid router ID "cdn10"`,
+		},
+		{
+			name:    "fails on an unknown router",
+			router:  "99",
+			wantErr: "router 99 not found",
+		},
+		{
+			name:    "fails on a malformed link",
+			link:    "a-b",
+			wantErr: `invalid router link "a-b", must be router IDs as A-B`,
+		},
+		{
+			name:    "fails on routers which aren't linked",
+			link:    "1-2",
+			wantErr: "routers 1 and 2 aren't linked",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findFailures(newGraph(t, sample), tt.router, tt.link)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantDisconnects, got.disconnects())
+		})
+	}
+}
+
+func Test_findSinglePointsOfFailure(t *testing.T) {
+	sample := sampleData()
+
+	got := findSinglePointsOfFailure(newGraph(t, sample))
+
+	routers := make([]routerHop, 0, len(got.Routers))
+	for _, failure := range got.Routers {
+		routers = append(routers, failure.Router)
+	}
+
+	assert.Equal(t, []routerHop{
+		hopOf(sample, 8), hopOf(sample, 9), hopOf(sample, 10), hopOf(sample, 11), hopOf(sample, 14), hopOf(sample, 15),
+	}, routers)
+	assert.Len(t, got.RouterLinks, 4)
+	assert.Equal(t, []graph.LocationFailure{
+		{Location: sample.Locations[3], Split: [][]api.Location{locationsOf(sample, 7, 8), locationsOf(sample, 5)}},
+		{Location: sample.Locations[7], Split: [][]api.Location{locationsOf(sample, 4, 5), locationsOf(sample, 7)}},
+	}, got.Locations)
+	assert.Len(t, got.LocationLinks, 4)
+	assert.True(t, got.disconnects())
+}
+
+func Test_parseRouterLink(t *testing.T) {
+	tests := []struct {
+		name    string
+		link    string
+		wantA   int
+		wantB   int
+		wantErr bool
+	}{
+		{name: "parses router IDs", link: "9-15", wantA: 9, wantB: 15},
+		{name: "keeps the order given", link: "15-9", wantA: 15, wantB: 9},
+		{name: "fails without a separator", link: "915", wantErr: true},
+		{name: "fails on IDs which aren't numbers", link: "a-b", wantErr: true},
+		{name: "fails on a missing ID", link: "9-", wantErr: true},
+		{name: "fails on more than two IDs", link: "9-14-15", wantErr: true},
+		{name: "fails on an empty link", link: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b, err := parseRouterLink(tt.link)
+			if tt.wantErr {
+				assert.EqualError(t, err, `invalid router link "`+tt.link+`", must be router IDs as A-B`)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantA, a)
+			assert.Equal(t, tt.wantB, b)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/rs/zerolog"
+
+	"router-location-connecter/validate"
+)
+
+// validateCommand reads router location data and prints its data-quality report, exiting non-zero when there are errors.
+// The data is streamed straight into the validator so storage is never touched
+func validateCommand(ctx context.Context, log zerolog.Logger, args []string) int {
+	var (
+		source sourceFlags
+		format string
+	)
+
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	setUsage(fs, "[flags]", "prints the data-quality report of router location data, exiting 1 when it has errors")
+	source.register(fs)
+	fs.StringVar(&format, "format", validate.FormatText, "format the report is written in, one of text|json")
+	_ = fs.Parse(args)
+
+	if _, err := validate.ParseFormat(format); err != nil {
+		log.Error().Err(err).Msg("invalid report format")
+		return 2
+	}
+
+	validator := validate.New()
+	if err := source.client().StreamRouterLocationData(ctx, validator); err != nil {
+		log.Error().Err(err).Msg("get router location data")
+		return 2
+	}
+
+	report := validator.Report()
+	if err := report.Write(os.Stdout, format); err != nil {
+		log.Error().Err(err).Msg("write validation report")
+		return 2
+	}
+
+	if report.Errors() > 0 {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,248 @@
+package diff
+
+import (
+	"sort"
+
+	"router-location-connecter/api"
+	"router-location-connecter/graph"
+)
+
+// Snapshot is the state of the topology at a point in time
+type Snapshot struct {
+	Routers   []api.Router
+	Locations []api.Location
+	Links     []LocationPair
+}
+
+// LocationPair identifies a link between two locations, SourceID is always the lower of the two IDs
+type LocationPair struct {
+	SourceID      int
+	DestinationID int
+}
+
+// FromGraph takes a snapshot of a router graph, links to locations which don't exist are left out as they're never stored
+func FromGraph(g *graph.Graph) Snapshot {
+	snapshot := Snapshot{
+		Routers:   g.Routers(),
+		Locations: g.Locations(),
+	}
+
+	for _, link := range g.LocationLinks() {
+		_, srcOK := g.Location(link.SourceID)
+		_, destOK := g.Location(link.DestinationID)
+		if !srcOK || !destOK {
+			continue
+		}
+
+		snapshot.Links = append(snapshot.Links, LocationPair{SourceID: link.SourceID, DestinationID: link.DestinationID})
+	}
+
+	return snapshot
+}
+
+// Link is a link between two locations
+type Link struct {
+	Source      api.Location `json:"source"`
+	Destination api.Location `json:"destination"`
+}
+
+// Rename is a location whose name changed
+type Rename struct {
+	Location api.Location `json:"location"`
+	// PreviousName is the name the location had before
+	PreviousName string `json:"previous_name"`
+}
+
+// Move is a router whose location changed
+type Move struct {
+	Router api.Router   `json:"router"`
+	From   api.Location `json:"from"`
+	To     api.Location `json:"to"`
+}
+
+// RouterLinksChange is a router whose router links changed, ignoring their order and duplicates
+type RouterLinksChange struct {
+	Router  api.Router `json:"router"`
+	Added   []int      `json:"added"`
+	Removed []int      `json:"removed"`
+}
+
+// Changes are the differences between two snapshots, each list is ordered by ID
+type Changes struct {
+	RoutersAdded       []api.Router        `json:"routers_added"`
+	RoutersRemoved     []api.Router        `json:"routers_removed"`
+	RoutersMoved       []Move              `json:"routers_moved"`
+	RouterLinksChanged []RouterLinksChange `json:"router_links_changed"`
+	LocationsAdded     []api.Location      `json:"locations_added"`
+	LocationsRemoved   []api.Location      `json:"locations_removed"`
+	LocationsRenamed   []Rename            `json:"locations_renamed"`
+	LinksAdded         []Link              `json:"links_added"`
+	LinksRemoved       []Link              `json:"links_removed"`
+}
+
+// Empty reports whether there are no changes
+func (c *Changes) Empty() bool {
+	return len(c.RoutersAdded) == 0 && len(c.RoutersRemoved) == 0 &&
+		len(c.RoutersMoved) == 0 && len(c.RouterLinksChanged) == 0 &&
+		len(c.LocationsAdded) == 0 && len(c.LocationsRemoved) == 0 && len(c.LocationsRenamed) == 0 &&
+		len(c.LinksAdded) == 0 && len(c.LinksRemoved) == 0
+}
+
+// Compare returns the changes needed to go from the before snapshot to the after snapshot
+func Compare(before, after Snapshot) *Changes {
+	changes := &Changes{}
+
+	beforeRouters := byID(before.Routers, func(r api.Router) int { return r.ID })
+	afterRouters := byID(after.Routers, func(r api.Router) int { return r.ID })
+
+	changes.RoutersAdded = missingFrom(afterRouters, beforeRouters)
+	changes.RoutersRemoved = missingFrom(beforeRouters, afterRouters)
+
+	beforeLocations := byID(before.Locations, func(l api.Location) int { return l.ID })
+	afterLocations := byID(after.Locations, func(l api.Location) int { return l.ID })
+
+	for _, id := range sortedIDs(afterRouters) {
+		previous, ok := beforeRouters[id]
+		if !ok {
+			continue
+		}
+
+		router := afterRouters[id]
+		if previous.LocationID != router.LocationID {
+			changes.RoutersMoved = append(changes.RoutersMoved, Move{
+				Router: router,
+				From:   locationOrID(beforeLocations, previous.LocationID),
+				To:     locationOrID(afterLocations, router.LocationID),
+			})
+		}
+
+		added, removed := compareLinks(previous.RouterLinks, router.RouterLinks)
+		if len(added) > 0 || len(removed) > 0 {
+			changes.RouterLinksChanged = append(changes.RouterLinksChanged, RouterLinksChange{
+				Router:  router,
+				Added:   added,
+				Removed: removed,
+			})
+		}
+	}
+
+	changes.LocationsAdded = missingFrom(afterLocations, beforeLocations)
+	changes.LocationsRemoved = missingFrom(beforeLocations, afterLocations)
+
+	for _, id := range sortedIDs(afterLocations) {
+		previous, ok := beforeLocations[id]
+		if ok && previous.Name != afterLocations[id].Name {
+			changes.LocationsRenamed = append(changes.LocationsRenamed, Rename{
+				Location:     afterLocations[id],
+				PreviousName: previous.Name,
+			})
+		}
+	}
+
+	changes.LinksAdded = linksMissingFrom(after.Links, before.Links, afterLocations)
+	changes.LinksRemoved = linksMissingFrom(before.Links, after.Links, beforeLocations)
+
+	return changes
+}
+
+// compareLinks returns the router links added and removed between two router links lists, ordered by ID
+func compareLinks(before, after []int) (added, removed []int) {
+	inBefore := make(map[int]struct{}, len(before))
+	for _, link := range before {
+		inBefore[link] = struct{}{}
+	}
+
+	inAfter := make(map[int]struct{}, len(after))
+	for _, link := range after {
+		inAfter[link] = struct{}{}
+	}
+
+	for _, link := range sortedIDs(inAfter) {
+		if _, ok := inBefore[link]; !ok {
+			added = append(added, link)
+		}
+	}
+
+	for _, link := range sortedIDs(inBefore) {
+		if _, ok := inAfter[link]; !ok {
+			removed = append(removed, link)
+		}
+	}
+
+	return added, removed
+}
+
+// byID indexes records by their ID
+func byID[T any](records []T, id func(T) int) map[int]T {
+	indexed := make(map[int]T, len(records))
+	for _, record := range records {
+		indexed[id(record)] = record
+	}
+
+	return indexed
+}
+
+// missingFrom returns the records of a which aren't in b, ordered by ID
+func missingFrom[T any](a, b map[int]T) []T {
+	var missing []T
+
+	for _, id := range sortedIDs(a) {
+		if _, ok := b[id]; !ok {
+			missing = append(missing, a[id])
+		}
+	}
+
+	return missing
+}
+
+// linksMissingFrom returns the links of a which aren't in b, looking up their locations in the snapshot of a
+func linksMissingFrom(a, b []LocationPair, locations map[int]api.Location) []Link {
+	inB := make(map[LocationPair]struct{}, len(b))
+	for _, pair := range b {
+		inB[pair] = struct{}{}
+	}
+
+	var missing []LocationPair
+	for _, pair := range a {
+		if _, ok := inB[pair]; !ok {
+			missing = append(missing, pair)
+		}
+	}
+
+	sort.Slice(missing, func(i, j int) bool {
+		if missing[i].SourceID != missing[j].SourceID {
+			return missing[i].SourceID < missing[j].SourceID
+		}
+		return missing[i].DestinationID < missing[j].DestinationID
+	})
+
+	var links []Link
+	for _, pair := range missing {
+		links = append(links, Link{
+			Source:      locationOrID(locations, pair.SourceID),
+			Destination: locationOrID(locations, pair.DestinationID),
+		})
+	}
+
+	return links
+}
+
+// locationOrID returns the location with the ID, or a location with just the ID when it isn't known
+func locationOrID(locations map[int]api.Location, id int) api.Location {
+	if location, ok := locations[id]; ok {
+		return location
+	}
+
+	return api.Location{ID: id}
+}
+
+func sortedIDs[T any](m map[int]T) []int {
+	ids := make([]int, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+
+	sort.Ints(ids)
+
+	return ids
+}
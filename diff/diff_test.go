@@ -0,0 +1,171 @@
+package diff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+)
+
+func TestCompare(t *testing.T) {
+	before := Snapshot{
+		Routers: []api.Router{
+			{ID: 1, Name: "citadel-01", LocationID: 1},
+			{ID: 2, Name: "citadel-02", LocationID: 2},
+		},
+		Locations: []api.Location{
+			{ID: 1, Name: "Birmingham Motorcycle Museum"},
+			{ID: 2, Name: "Birmingham Hippodrome"},
+			{ID: 3, Name: "Winterbourne House"},
+		},
+		Links: []LocationPair{{SourceID: 1, DestinationID: 2}},
+	}
+
+	tests := []struct {
+		name  string
+		after Snapshot
+		want  *Changes
+	}{
+		{
+			name:  "finds no changes between identical snapshots",
+			after: before,
+			want:  &Changes{},
+		},
+		{
+			name: "finds added, removed and renamed records",
+			after: Snapshot{
+				Routers: []api.Router{
+					{ID: 2, Name: "citadel-02", LocationID: 2},
+					{ID: 3, Name: "core-07", LocationID: 3},
+				},
+				Locations: []api.Location{
+					{ID: 2, Name: "Birmingham Hippodrome Theatre"},
+					{ID: 3, Name: "Winterbourne House"},
+					{ID: 4, Name: "Lancaster Brewery"},
+				},
+				Links: []LocationPair{{SourceID: 2, DestinationID: 3}},
+			},
+			want: &Changes{
+				RoutersAdded:     []api.Router{{ID: 3, Name: "core-07", LocationID: 3}},
+				RoutersRemoved:   []api.Router{{ID: 1, Name: "citadel-01", LocationID: 1}},
+				LocationsAdded:   []api.Location{{ID: 4, Name: "Lancaster Brewery"}},
+				LocationsRemoved: []api.Location{{ID: 1, Name: "Birmingham Motorcycle Museum"}},
+				LocationsRenamed: []Rename{{
+					Location:     api.Location{ID: 2, Name: "Birmingham Hippodrome Theatre"},
+					PreviousName: "Birmingham Hippodrome",
+				}},
+				LinksAdded: []Link{{
+					Source:      api.Location{ID: 2, Name: "Birmingham Hippodrome Theatre"},
+					Destination: api.Location{ID: 3, Name: "Winterbourne House"},
+				}},
+				LinksRemoved: []Link{{
+					Source:      api.Location{ID: 1, Name: "Birmingham Motorcycle Museum"},
+					Destination: api.Location{ID: 2, Name: "Birmingham Hippodrome"},
+				}},
+			},
+		},
+		{
+			name: "finds moved routers and changed router links",
+			after: Snapshot{
+				Routers: []api.Router{
+					{ID: 1, Name: "citadel-01", LocationID: 3, RouterLinks: []int{2, 2}},
+					{ID: 2, Name: "citadel-02", LocationID: 2},
+				},
+				Locations: before.Locations,
+				Links:     before.Links,
+			},
+			want: &Changes{
+				RoutersMoved: []Move{{
+					Router: api.Router{ID: 1, Name: "citadel-01", LocationID: 3, RouterLinks: []int{2, 2}},
+					From:   api.Location{ID: 1, Name: "Birmingham Motorcycle Museum"},
+					To:     api.Location{ID: 3, Name: "Winterbourne House"},
+				}},
+				RouterLinksChanged: []RouterLinksChange{{
+					Router: api.Router{ID: 1, Name: "citadel-01", LocationID: 3, RouterLinks: []int{2, 2}},
+					Added:  []int{2},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compare(before, tt.after)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want.Empty(), got.Empty())
+		})
+	}
+}
+
+func TestChanges_Events(t *testing.T) {
+	changes := &Changes{
+		RoutersAdded: []api.Router{{ID: 3, Name: "core-07", LocationID: 3}},
+		LocationsRenamed: []Rename{{
+			Location:     api.Location{ID: 2, Name: "Birmingham Hippodrome Theatre"},
+			PreviousName: "Birmingham Hippodrome",
+		}},
+		LinksRemoved: []Link{{Source: api.Location{ID: 1}, Destination: api.Location{ID: 2}}},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, NewWriterSink(&out).Send(context.Background(), changes.Events()))
+
+	assert.Equal(t, `{"type":"link_removed","link":{"source":{"id":1,"postcode":"","name":""},"destination":{"id":2,"postcode":"","name":""}}}
+{"type":"location_renamed","location":{"id":2,"postcode":"","name":"Birmingham Hippodrome Theatre"},"previous_name":"Birmingham Hippodrome"}
+{"type":"router_added","router":{"id":3,"name":"core-07","location_id":3,"router_links":null}}
+`, out.String())
+}
+
+func TestWebhookSink_Send(t *testing.T) {
+	events := []Event{{Type: LocationAdded, Location: &api.Location{ID: 4, Name: "Lancaster Brewery"}}}
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr string
+	}{
+		{
+			name:   "posts the events",
+			status: http.StatusNoContent,
+		},
+		{
+			name:    "returns an error when the webhook doesn't accept the events",
+			status:  http.StatusBadGateway,
+			wantErr: "unexpected webhook response status code: 502",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct {
+				Events []Event `json:"events"`
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.NoError(t, json.Unmarshal(body, &got))
+
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := NewWebhookSink(server.URL, time.Second).Send(context.Background(), events)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, events, got.Events)
+		})
+	}
+}
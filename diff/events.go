@@ -0,0 +1,162 @@
+package diff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"router-location-connecter/api"
+)
+
+// EventType is the kind of change an event describes
+type EventType string
+
+const (
+	RouterAdded   EventType = "router_added"
+	RouterRemoved EventType = "router_removed"
+	RouterMoved   EventType = "router_moved"
+	// RouterLinksChanged events carry the router with its new router links
+	RouterLinksChanged EventType = "router_links_changed"
+	LocationAdded      EventType = "location_added"
+	LocationRemoved    EventType = "location_removed"
+	LocationRenamed    EventType = "location_renamed"
+	LinkAdded          EventType = "link_added"
+	LinkRemoved        EventType = "link_removed"
+)
+
+// Event is a single change to the topology, only the field for the kind of change is set
+type Event struct {
+	Type     EventType     `json:"type"`
+	Router   *api.Router   `json:"router,omitempty"`
+	Location *api.Location `json:"location,omitempty"`
+	// PreviousName is set for renamed locations
+	PreviousName string `json:"previous_name,omitempty"`
+	Link         *Link  `json:"link,omitempty"`
+	// PreviousLocation is set for moved routers, Location being the router's new location
+	PreviousLocation *api.Location `json:"previous_location,omitempty"`
+}
+
+// Events lists the changes as events, removals first so consumers can apply them in order
+func (c *Changes) Events() []Event {
+	var events []Event
+
+	for _, link := range c.LinksRemoved {
+		events = append(events, Event{Type: LinkRemoved, Link: &link})
+	}
+
+	for _, router := range c.RoutersRemoved {
+		events = append(events, Event{Type: RouterRemoved, Router: &router})
+	}
+
+	for _, location := range c.LocationsRemoved {
+		events = append(events, Event{Type: LocationRemoved, Location: &location})
+	}
+
+	for _, rename := range c.LocationsRenamed {
+		events = append(events, Event{Type: LocationRenamed, Location: &rename.Location, PreviousName: rename.PreviousName})
+	}
+
+	for _, location := range c.LocationsAdded {
+		events = append(events, Event{Type: LocationAdded, Location: &location})
+	}
+
+	for _, router := range c.RoutersAdded {
+		events = append(events, Event{Type: RouterAdded, Router: &router})
+	}
+
+	for _, move := range c.RoutersMoved {
+		events = append(events, Event{Type: RouterMoved, Router: &move.Router, Location: &move.To, PreviousLocation: &move.From})
+	}
+
+	for _, change := range c.RouterLinksChanged {
+		events = append(events, Event{Type: RouterLinksChanged, Router: &change.Router})
+	}
+
+	for _, link := range c.LinksAdded {
+		events = append(events, Event{Type: LinkAdded, Link: &link})
+	}
+
+	return events
+}
+
+// Sink receives the events of each sync with changes
+type Sink interface {
+	Send(ctx context.Context, events []Event) error
+}
+
+// WriterSink writes each event as a line of JSON
+type WriterSink struct {
+	w io.Writer
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ Sink = (*WriterSink)(nil)
+
+// NewWriterSink initializes a sink writing newline delimited JSON events
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) Send(_ context.Context, events []Event) error {
+	enc := json.NewEncoder(s.w)
+
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WebhookSink posts the events of each sync to a URL as a JSON object
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ Sink = (*WebhookSink)(nil)
+
+// NewWebhookSink initializes a sink posting events to the URL, giving up on a request after the timeout
+func NewWebhookSink(url string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *WebhookSink) Send(ctx context.Context, events []Event) error {
+	body, err := json.Marshal(struct {
+		Events []Event `json:"events"`
+	}{Events: events})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post events to webhook: %w", err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected webhook response status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"router-location-connecter/api"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// ParseFormat checks a flag value is a report format
+func ParseFormat(format string) (string, error) {
+	switch format {
+	case FormatText, FormatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown diff format %q, must be one of %s|%s", format, FormatText, FormatJSON)
+	}
+}
+
+// Write writes the changes in the given format
+func (c *Changes) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatText:
+		return c.WriteText(w)
+	case FormatJSON:
+		return c.WriteJSON(w)
+	default:
+		return fmt.Errorf("unknown diff format %q", format)
+	}
+}
+
+// WriteText writes one line per change prefixed with + for additions, - for removals and ~ for modifications
+func (c *Changes) WriteText(w io.Writer) error {
+	var lines []string
+
+	for _, link := range c.LinksAdded {
+		lines = append(lines, fmt.Sprintf("+ link [%s] <-> [%s]", link.Source.Name, link.Destination.Name))
+	}
+
+	for _, link := range c.LinksRemoved {
+		lines = append(lines, fmt.Sprintf("- link [%s] <-> [%s]", link.Source.Name, link.Destination.Name))
+	}
+
+	for _, router := range c.RoutersAdded {
+		lines = append(lines, fmt.Sprintf("+ router %s", routerName(router)))
+	}
+
+	for _, router := range c.RoutersRemoved {
+		lines = append(lines, fmt.Sprintf("- router %s", routerName(router)))
+	}
+
+	for _, move := range c.RoutersMoved {
+		lines = append(lines, fmt.Sprintf("~ router %s moved [%s] -> [%s]",
+			routerName(move.Router), locationName(move.From), locationName(move.To)))
+	}
+
+	for _, change := range c.RouterLinksChanged {
+		lines = append(lines, fmt.Sprintf("~ router %s links added %v removed %v",
+			routerName(change.Router), ids(change.Added), ids(change.Removed)))
+	}
+
+	for _, location := range c.LocationsAdded {
+		lines = append(lines, fmt.Sprintf("+ location %d [%s]", location.ID, location.Name))
+	}
+
+	for _, location := range c.LocationsRemoved {
+		lines = append(lines, fmt.Sprintf("- location %d [%s]", location.ID, location.Name))
+	}
+
+	for _, rename := range c.LocationsRenamed {
+		lines = append(lines, fmt.Sprintf("~ location %d renamed [%s] -> [%s]",
+			rename.Location.ID, rename.PreviousName, rename.Location.Name))
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, "no changes")
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+
+	return err
+}
+
+// WriteJSON writes the changes as an indented JSON object, with empty lists rather than nulls
+func (c *Changes) WriteJSON(w io.Writer) error {
+	normalized := Changes{
+		RoutersAdded:       orEmpty(c.RoutersAdded),
+		RoutersRemoved:     orEmpty(c.RoutersRemoved),
+		RoutersMoved:       orEmpty(c.RoutersMoved),
+		RouterLinksChanged: make([]RouterLinksChange, 0, len(c.RouterLinksChanged)),
+		LocationsAdded:     orEmpty(c.LocationsAdded),
+		LocationsRemoved:   orEmpty(c.LocationsRemoved),
+		LocationsRenamed:   orEmpty(c.LocationsRenamed),
+		LinksAdded:         orEmpty(c.LinksAdded),
+		LinksRemoved:       orEmpty(c.LinksRemoved),
+	}
+
+	for _, change := range c.RouterLinksChanged {
+		change.Added = orEmpty(change.Added)
+		change.Removed = orEmpty(change.Removed)
+		normalized.RouterLinksChanged = append(normalized.RouterLinksChanged, change)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(normalized)
+}
+
+func routerName(router api.Router) string {
+	return fmt.Sprintf("%d (%s)", router.ID, router.Name)
+}
+
+// locationName falls back to the ID for locations which don't exist
+func locationName(location api.Location) string {
+	if location.Name == "" {
+		return fmt.Sprintf("location %d", location.ID)
+	}
+
+	return location.Name
+}
+
+// ids formats router IDs as [1 2 3], or [] when there are none
+func ids(list []int) string {
+	return fmt.Sprint(orEmpty(list))
+}
+
+func orEmpty[T any](list []T) []T {
+	if list == nil {
+		return []T{}
+	}
+
+	return list
+}
@@ -0,0 +1,104 @@
+package diff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+)
+
+func TestChanges_Write(t *testing.T) {
+	changes := &Changes{
+		RoutersMoved: []Move{{
+			Router: api.Router{ID: 1, Name: "citadel-01", LocationID: 3},
+			From:   api.Location{ID: 1, Name: "Birmingham Motorcycle Museum"},
+			To:     api.Location{ID: 3},
+		}},
+		RouterLinksChanged: []RouterLinksChange{{
+			Router:  api.Router{ID: 2, Name: "citadel-02", LocationID: 2, RouterLinks: []int{4}},
+			Added:   []int{4},
+			Removed: []int{1, 3},
+		}},
+		LinksAdded: []Link{{
+			Source:      api.Location{ID: 2, Name: "Birmingham Hippodrome"},
+			Destination: api.Location{ID: 6, Name: "Williamson Park"},
+		}},
+	}
+
+	tests := []struct {
+		name    string
+		changes *Changes
+		format  string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "text lists each change",
+			changes: changes,
+			format:  FormatText,
+			want: "+ link [Birmingham Hippodrome] <-> [Williamson Park]\n" +
+				"~ router 1 (citadel-01) moved [Birmingham Motorcycle Museum] -> [location 3]\n" +
+				"~ router 2 (citadel-02) links added [4] removed [1 3]\n",
+		},
+		{
+			name:    "text says when there are no changes",
+			changes: &Changes{},
+			format:  FormatText,
+			want:    "no changes\n",
+		},
+		{
+			name: "json writes empty lists rather than nulls",
+			changes: &Changes{RouterLinksChanged: []RouterLinksChange{{
+				Router:  api.Router{ID: 2, Name: "citadel-02", LocationID: 2, RouterLinks: []int{}},
+				Removed: []int{1},
+			}}},
+			format: FormatJSON,
+			want: `{
+  "routers_added": [],
+  "routers_removed": [],
+  "routers_moved": [],
+  "router_links_changed": [
+    {
+      "router": {
+        "id": 2,
+        "name": "citadel-02",
+        "location_id": 2,
+        "router_links": []
+      },
+      "added": [],
+      "removed": [
+        1
+      ]
+    }
+  ],
+  "locations_added": [],
+  "locations_removed": [],
+  "locations_renamed": [],
+  "links_added": [],
+  "links_removed": []
+}
+`,
+		},
+		{
+			name:    "fails for an unknown format",
+			changes: changes,
+			format:  "csv",
+			wantErr: `unknown diff format "csv"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := tt.changes.Write(&out, tt.format)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
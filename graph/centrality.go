@@ -0,0 +1,260 @@
+package graph
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+)
+
+// _sampleSeed seeds the choice of sources sampled when estimating centrality
+const _sampleSeed = 1
+
+// Metric is a centrality measure routers and locations are ranked by
+type Metric string
+
+const (
+	// Degree ranks by the number of routers or locations linked to
+	Degree Metric = "degree"
+	// Betweenness ranks by the share of shortest paths between other routers or locations passing through
+	Betweenness Metric = "betweenness"
+	// Closeness ranks by how few hops the routers or locations reachable are away
+	Closeness Metric = "closeness"
+)
+
+// ParseMetric converts a flag value to a Metric
+func ParseMetric(metric string) (Metric, error) {
+	switch m := Metric(metric); m {
+	case Degree, Betweenness, Closeness:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown centrality metric %q, must be one of %s|%s|%s", metric, Degree, Betweenness, Closeness)
+	}
+}
+
+// Centrality is how central a router or location is to the graph. Betweenness is normalised to between 0 and 1, and
+// closeness is scaled by the share of the graph reachable so routers or locations in small components rank lower
+type Centrality struct {
+	ID          int     `json:"id"`
+	Degree      int     `json:"degree"`
+	Betweenness float64 `json:"betweenness"`
+	Closeness   float64 `json:"closeness"`
+}
+
+// CentralityOption specifies a builder function for configuring how centrality is computed
+type CentralityOption func(*centralityOptions)
+
+type centralityOptions struct {
+	samples int
+}
+
+// WithSamples estimates betweenness and closeness from searches of the graph from this many routers or locations rather
+// than every one, trading accuracy for time on large graphs. 0, the default, searches from every one
+func WithSamples(samples int) CentralityOption {
+	return func(o *centralityOptions) {
+		o.samples = samples
+	}
+}
+
+func newCentralityOptions(opts []CentralityOption) centralityOptions {
+	var o centralityOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// RouterCentrality returns the centrality of every router over the router links, ordered by ID
+func (g *Graph) RouterCentrality(opts ...CentralityOption) []Centrality {
+	edges := make(map[node]map[node]struct{}, len(g.routers))
+
+	for id := range g.routers {
+		neighbours := make(map[node]struct{}, len(g.adjacency[id]))
+		for link := range g.adjacency[id] {
+			neighbours[node{router: true, id: link}] = struct{}{}
+		}
+
+		edges[node{router: true, id: id}] = neighbours
+	}
+
+	return newUndirected(edges).centrality(newCentralityOptions(opts).samples)
+}
+
+// LocationCentrality returns the centrality of every location over the location links, ordered by ID
+func (g *Graph) LocationCentrality(opts ...CentralityOption) []Centrality {
+	return g.locationGraph().centrality(newCentralityOptions(opts).samples)
+}
+
+// Rank orders centralities most central first by the metric, ties broken by the other metrics then by ID
+func Rank(centralities []Centrality, metric Metric) []Centrality {
+	ranked := append([]Centrality(nil), centralities...)
+
+	// the chosen metric is compared first, then the rest in their declared order
+	order := []Metric{metric, Degree, Betweenness, Closeness}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		for _, m := range order {
+			a, b := ranked[i].value(m), ranked[j].value(m)
+			if a != b {
+				return a > b
+			}
+		}
+
+		return ranked[i].ID < ranked[j].ID
+	})
+
+	return ranked
+}
+
+func (c Centrality) value(metric Metric) float64 {
+	switch metric {
+	case Degree:
+		return float64(c.Degree)
+	case Betweenness:
+		return c.Betweenness
+	default:
+		return c.Closeness
+	}
+}
+
+// centrality computes the centrality of every node with Brandes' algorithm, running a breadth first search from each
+// source. With samples fewer than the nodes only that many sources are searched, and betweenness and closeness are
+// estimated from them
+func (u undirected) centrality(samples int) []Centrality {
+	nodes := u.nodes()
+	n := len(nodes)
+
+	// nodes are indexed densely so the searches work on slices reused across sources rather than maps
+	index := make(map[node]int, n)
+	for i, current := range nodes {
+		index[current] = i
+	}
+
+	adjacency := make([][]int, n)
+	for i, current := range nodes {
+		adjacency[i] = make([]int, 0, len(u[current]))
+		for _, next := range u[current] {
+			adjacency[i] = append(adjacency[i], index[next])
+		}
+	}
+
+	sources := sampleSources(n, samples)
+
+	var (
+		betweenness = make([]float64, n)
+		// closeness is worked out from each node's side, from how many sources reach it and how many hops away they are
+		// in total, so it can be estimated from sampled sources
+		reached    = make([]int, n)
+		hops       = make([]int, n)
+		distance   = make([]int, n)
+		paths      = make([]float64, n)
+		dependency = make([]float64, n)
+		order      = make([]int, 0, n)
+	)
+
+	for i := range distance {
+		distance[i] = -1
+	}
+
+	for _, source := range sources {
+		distance[source] = 0
+		paths[source] = 1
+		order = append(order[:0], source)
+
+		// order doubles as the queue of the breadth first search
+		for head := 0; head < len(order); head++ {
+			current := order[head]
+			if current != source {
+				reached[current]++
+				hops[current] += distance[current]
+			}
+
+			for _, next := range adjacency[current] {
+				if distance[next] < 0 {
+					distance[next] = distance[current] + 1
+					order = append(order, next)
+				}
+
+				if distance[next] == distance[current]+1 {
+					paths[next] += paths[current]
+				}
+			}
+		}
+
+		// accumulate the dependency of the source on each node, furthest first. The predecessors of a node are its
+		// neighbours a hop closer to the source
+		for i := len(order) - 1; i >= 0; i-- {
+			current := order[i]
+			for _, previous := range adjacency[current] {
+				if distance[previous] == distance[current]-1 {
+					dependency[previous] += paths[previous] / paths[current] * (1 + dependency[current])
+				}
+			}
+
+			if current != source {
+				betweenness[current] += dependency[current]
+			}
+		}
+
+		// only the nodes reached were touched, so only they're reset for the next source
+		for _, current := range order {
+			distance[current] = -1
+			paths[current] = 0
+			dependency[current] = 0
+		}
+	}
+
+	// every pair is counted from both ends, normalising by the pairs of other nodes there are and scaling up from the
+	// sources searched
+	scale := 0.0
+	if n > 2 {
+		scale = float64(n) / float64(len(sources)) / float64((n-1)*(n-2))
+	}
+
+	searched := make([]bool, n)
+	for _, source := range sources {
+		searched[source] = true
+	}
+
+	centralities := make([]Centrality, 0, n)
+	for i, current := range nodes {
+		centrality := Centrality{
+			ID:          current.id,
+			Degree:      len(adjacency[i]),
+			Betweenness: betweenness[i] * scale,
+		}
+
+		// Wasserman and Faust's closeness, scaling by the share of the other nodes reachable. The other sources searched
+		// stand in for the other nodes, as every one is searched without sampling
+		others := len(sources)
+		if searched[i] {
+			others--
+		}
+
+		if hops[i] > 0 && others > 0 {
+			centrality.Closeness = float64(reached[i]) / float64(hops[i]) * float64(reached[i]) / float64(others)
+		}
+
+		centralities = append(centralities, centrality)
+	}
+
+	return centralities
+}
+
+// sampleSources returns the indexes of the nodes searched from, every one unless samples is fewer than the nodes. The
+// sample is pseudo-random but the same every time, so rankings are repeatable
+func sampleSources(n, samples int) []int {
+	if samples <= 0 || samples >= n {
+		sources := make([]int, n)
+		for i := range sources {
+			sources[i] = i
+		}
+
+		return sources
+	}
+
+	sources := rand.New(rand.NewPCG(_sampleSeed, _sampleSeed)).Perm(n)[:samples]
+	sort.Ints(sources)
+
+	return sources
+}
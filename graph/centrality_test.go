@@ -0,0 +1,132 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+)
+
+func TestGraph_LocationCentrality(t *testing.T) {
+	g, err := New(sampleData())
+	assert.NoError(t, err)
+
+	centralities := g.LocationCentrality()
+	assert.Len(t, centralities, 8)
+
+	want := map[int]Centrality{
+		1: {ID: 1},
+		2: {ID: 2, Degree: 1, Closeness: 1.0 / 7},
+		4: {ID: 4, Degree: 2, Betweenness: 2.0 / 21, Closeness: 3.0 / 4 * 3.0 / 7},
+		5: {ID: 5, Degree: 1, Closeness: 3.0 / 6 * 3.0 / 7},
+		8: {ID: 8, Degree: 2, Betweenness: 2.0 / 21, Closeness: 3.0 / 4 * 3.0 / 7},
+	}
+
+	for _, centrality := range centralities {
+		expected, ok := want[centrality.ID]
+		if !ok {
+			continue
+		}
+
+		assert.Equal(t, expected.Degree, centrality.Degree, "location %d", centrality.ID)
+		assert.InDelta(t, expected.Betweenness, centrality.Betweenness, 1e-9, "location %d", centrality.ID)
+		assert.InDelta(t, expected.Closeness, centrality.Closeness, 1e-9, "location %d", centrality.ID)
+	}
+}
+
+func TestGraph_RouterCentrality(t *testing.T) {
+	g, err := New(sampleData())
+	assert.NoError(t, err)
+
+	centralities := g.RouterCentrality()
+	assert.Len(t, centralities, 13)
+
+	byID := make(map[int]Centrality)
+	for _, centrality := range centralities {
+		byID[centrality.ID] = centrality
+	}
+
+	// routers 3, 15, 9 and 14 form a line with 4 and 10 hanging off 14
+	assert.Equal(t, 3, byID[14].Degree)
+	assert.InDelta(t, 7.0/66, byID[14].Betweenness, 1e-9)
+	assert.InDelta(t, 6.0/66, byID[9].Betweenness, 1e-9)
+	assert.InDelta(t, 4.0/66, byID[15].Betweenness, 1e-9)
+	assert.Zero(t, byID[1].Betweenness)
+	assert.Zero(t, byID[1].Closeness)
+
+	// routers 4 and 8 tie on betweenness and degree, 4 being closer to the routers it reaches
+	ranked := Rank(centralities, Betweenness)
+	assert.Equal(t, []int{14, 9, 15, 5, 4}, ids(ranked[:5]))
+
+	// routers 9, 15 and 5 tie on degree so are ordered by betweenness
+	ranked = Rank(centralities, Degree)
+	assert.Equal(t, []int{14, 9, 15, 5}, ids(ranked[:4]))
+}
+
+func TestGraph_LocationCentrality_Samples(t *testing.T) {
+	g, err := New(sampleData())
+	assert.NoError(t, err)
+
+	exact := g.LocationCentrality()
+
+	// sampling as many or more sources than there are locations searches from every one
+	assert.Equal(t, exact, g.LocationCentrality(WithSamples(8)))
+	assert.Equal(t, exact, g.LocationCentrality(WithSamples(20)))
+
+	// the sources sampled are the same every time
+	sampled := g.LocationCentrality(WithSamples(4))
+	assert.Equal(t, sampled, g.LocationCentrality(WithSamples(4)))
+	assert.Equal(t, ids(exact), ids(sampled))
+
+	for i, centrality := range sampled {
+		assert.Equal(t, exact[i].Degree, centrality.Degree, "location %d", centrality.ID)
+	}
+}
+
+func TestGraph_RouterCentrality_Samples(t *testing.T) {
+	// five routers all linked to each other, each at its own location, are a hop from every source so sampling
+	// estimates them exactly
+	data := &api.RouterLocationData{}
+	for id := 1; id <= 5; id++ {
+		links := make([]int, 0, 4)
+		for link := 1; link <= 5; link++ {
+			if link != id {
+				links = append(links, link)
+			}
+		}
+
+		data.Routers = append(data.Routers, api.Router{ID: id, Name: fmt.Sprintf("r%d", id), LocationID: id, RouterLinks: links})
+		data.Locations = append(data.Locations, api.Location{ID: id, Name: fmt.Sprintf("l%d", id)})
+	}
+
+	g, err := New(data)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Centrality{
+		{ID: 1, Degree: 4, Closeness: 1},
+		{ID: 2, Degree: 4, Closeness: 1},
+		{ID: 3, Degree: 4, Closeness: 1},
+		{ID: 4, Degree: 4, Closeness: 1},
+		{ID: 5, Degree: 4, Closeness: 1},
+	}, g.RouterCentrality(WithSamples(2)))
+}
+
+func TestParseMetric(t *testing.T) {
+	metric, err := ParseMetric("closeness")
+	assert.NoError(t, err)
+	assert.Equal(t, Closeness, metric)
+
+	_, err = ParseMetric("pagerank")
+	assert.EqualError(t, err, `unknown centrality metric "pagerank", must be one of degree|betweenness|closeness`)
+}
+
+func ids(centralities []Centrality) []int {
+	ids := make([]int, 0, len(centralities))
+	for _, centrality := range centralities {
+		ids = append(ids, centrality.ID)
+	}
+
+	return ids
+}
@@ -0,0 +1,142 @@
+package graph
+
+import (
+	"sort"
+)
+
+// Component is a set of locations and routers connected to each other, through router links or routers sharing a
+// location. Both are ordered by ID
+type Component struct {
+	Locations []int `json:"locations"`
+	Routers   []int `json:"routers"`
+}
+
+// node is a location or router in the graph, as their IDs overlap
+type node struct {
+	router bool
+	id     int
+}
+
+// Components groups the locations and routers into connected components, largest first by number of locations then
+// routers, ties ordered by lowest location ID. Routers at an unknown location are grouped by their router links alone
+func (g *Graph) Components() []Component {
+	parent := make(map[node]node)
+
+	var find func(n node) node
+	find = func(n node) node {
+		if parent[n] != n {
+			parent[n] = find(parent[n])
+		}
+
+		return parent[n]
+	}
+
+	union := func(a, b node) {
+		rootA, rootB := find(a), find(b)
+		if rootA != rootB {
+			parent[rootA] = rootB
+		}
+	}
+
+	for id := range g.locations {
+		n := node{id: id}
+		parent[n] = n
+	}
+
+	for id := range g.routers {
+		n := node{router: true, id: id}
+		parent[n] = n
+	}
+
+	for id, router := range g.routers {
+		if _, ok := g.locations[router.LocationID]; ok {
+			union(node{router: true, id: id}, node{id: router.LocationID})
+		}
+
+		for link := range g.adjacency[id] {
+			union(node{router: true, id: id}, node{router: true, id: link})
+		}
+	}
+
+	byRoot := make(map[node]*Component)
+	for n := range parent {
+		root := find(n)
+
+		component, ok := byRoot[root]
+		if !ok {
+			component = &Component{Locations: make([]int, 0), Routers: make([]int, 0)}
+			byRoot[root] = component
+		}
+
+		if n.router {
+			component.Routers = append(component.Routers, n.id)
+		} else {
+			component.Locations = append(component.Locations, n.id)
+		}
+	}
+
+	components := make([]Component, 0, len(byRoot))
+	for _, component := range byRoot {
+		sort.Ints(component.Locations)
+		sort.Ints(component.Routers)
+
+		components = append(components, *component)
+	}
+
+	sort.Slice(components, func(i, j int) bool {
+		a, b := components[i], components[j]
+		if len(a.Locations) != len(b.Locations) {
+			return len(a.Locations) > len(b.Locations)
+		}
+		if len(a.Routers) != len(b.Routers) {
+			return len(a.Routers) > len(b.Routers)
+		}
+
+		return lowestID(a) < lowestID(b)
+	})
+
+	return components
+}
+
+// IsolatedLocations returns the locations not linked to any other location, ordered by ID
+func (g *Graph) IsolatedLocations() []int {
+	linked := make(map[int]struct{})
+	for _, link := range g.LocationLinks() {
+		linked[link.SourceID] = struct{}{}
+		linked[link.DestinationID] = struct{}{}
+	}
+
+	isolated := make([]int, 0)
+	for _, id := range sortedKeys(g.locations) {
+		if _, ok := linked[id]; !ok {
+			isolated = append(isolated, id)
+		}
+	}
+
+	return isolated
+}
+
+// IsolatedRouters returns the routers not linked to any other router, ordered by ID
+func (g *Graph) IsolatedRouters() []int {
+	isolated := make([]int, 0)
+	for _, id := range sortedKeys(g.routers) {
+		if len(g.adjacency[id]) == 0 {
+			isolated = append(isolated, id)
+		}
+	}
+
+	return isolated
+}
+
+// lowestID orders components with equal sizes, by location ID or by router ID for components without locations
+func lowestID(c Component) int {
+	if len(c.Locations) > 0 {
+		return c.Locations[0]
+	}
+
+	if len(c.Routers) > 0 {
+		return c.Routers[0]
+	}
+
+	return 0
+}
@@ -0,0 +1,43 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+)
+
+func TestGraph_Components(t *testing.T) {
+	g, err := New(sampleData())
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Component{
+		{Locations: []int{4, 5, 7, 8}, Routers: []int{3, 4, 9, 10, 14, 15}},
+		{Locations: []int{2, 6}, Routers: []int{8, 11}},
+		{Locations: []int{3}, Routers: []int{5, 6, 7}},
+		{Locations: []int{1}, Routers: []int{1, 2}},
+	}, g.Components())
+
+	assert.Equal(t, []int{1, 3}, g.IsolatedLocations())
+	assert.Equal(t, []int{1, 2}, g.IsolatedRouters())
+}
+
+func TestGraph_Components_UnknownLocation(t *testing.T) {
+	// router 2 is at a location which doesn't exist and router 3 links to nothing
+	g, err := New(&api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, LocationID: 1, RouterLinks: []int{2}},
+			{ID: 2, LocationID: 9, RouterLinks: []int{1}},
+			{ID: 3, LocationID: 9, RouterLinks: []int{}},
+		},
+		Locations: []api.Location{{ID: 1}, {ID: 2}},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Component{
+		{Locations: []int{1}, Routers: []int{1, 2}},
+		{Locations: []int{2}, Routers: []int{}},
+		{Locations: []int{}, Routers: []int{3}},
+	}, g.Components())
+}
@@ -0,0 +1,500 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"router-location-connecter/api"
+)
+
+// undirected is an undirected graph of locations and routers, neighbours are ordered locations first then by ID
+type undirected map[node][]node
+
+// Failures looks up what routers, router links, locations and location links failing would disconnect. The graphs
+// searched are built once so any number of failures can be looked up without rebuilding them
+type Failures struct {
+	graph     *Graph
+	sites     undirected
+	locations undirected
+}
+
+// SinglePoints is every router, router link, location and location link whose failure would disconnect locations
+type SinglePoints struct {
+	Routers       []RouterFailure       `json:"routers"`
+	RouterLinks   []RouterLinkFailure   `json:"router_links"`
+	Locations     []LocationFailure     `json:"locations"`
+	LocationLinks []LocationLinkFailure `json:"location_links"`
+}
+
+// RouterFailure is a router failing with the groups of locations it leaves disconnected from each other, largest
+// first, ties ordered by lowest location ID. Split is empty when every location stays connected
+type RouterFailure struct {
+	Router api.Router       `json:"router"`
+	Split  [][]api.Location `json:"split"`
+}
+
+// RouterLinkFailure is the link between two routers failing with the groups of locations it leaves disconnected
+type RouterLinkFailure struct {
+	A     api.Router       `json:"a"`
+	B     api.Router       `json:"b"`
+	Split [][]api.Location `json:"split"`
+}
+
+// LocationFailure is a location, and every router at it, failing with the groups of locations it leaves disconnected
+type LocationFailure struct {
+	Location api.Location     `json:"location"`
+	Split    [][]api.Location `json:"split"`
+}
+
+// LocationLinkFailure is the link between two locations, every router link producing it, failing with the groups of
+// locations it leaves disconnected
+type LocationLinkFailure struct {
+	Source      api.Location     `json:"source"`
+	Destination api.Location     `json:"destination"`
+	Split       [][]api.Location `json:"split"`
+}
+
+// ArticulationRouters returns the routers whose failure would disconnect the routers and locations connected through
+// them, ordered by ID. A router's location stays connected through any other router at it
+func (g *Graph) ArticulationRouters() []int {
+	articulation, _ := g.siteGraph().cuts()
+
+	return articulationRouters(articulation)
+}
+
+// BridgeLinks returns the router links whose failure would disconnect the routers and locations connected through
+// them, with A being the lower router ID, ordered by A then B
+func (g *Graph) BridgeLinks() []RouterPair {
+	_, bridges := g.siteGraph().cuts()
+
+	return bridgeLinks(bridges)
+}
+
+// ArticulationLocations returns the locations whose failure, taking every router at them down, would disconnect the
+// locations linked through them, ordered by ID
+func (g *Graph) ArticulationLocations() []int {
+	articulation, _ := g.locationGraph().cuts()
+
+	return articulationLocations(articulation)
+}
+
+// BridgeLocationLinks returns the location links whose failure would disconnect the locations linked through them,
+// ordered by source then destination ID
+func (g *Graph) BridgeLocationLinks() []LocationLink {
+	_, bridges := g.locationGraph().cuts()
+
+	return g.bridgeLocationLinks(bridges)
+}
+
+// Failures builds the graphs failures are looked up in
+func (g *Graph) Failures() *Failures {
+	return &Failures{
+		graph:     g,
+		sites:     g.siteGraph(),
+		locations: g.locationGraph(),
+	}
+}
+
+// SinglePoints returns every articulation router and location and every bridge link with what its failure splits,
+// leaving out those which disconnect no locations
+func (f *Failures) SinglePoints() SinglePoints {
+	points := SinglePoints{
+		Routers:       make([]RouterFailure, 0),
+		RouterLinks:   make([]RouterLinkFailure, 0),
+		Locations:     make([]LocationFailure, 0),
+		LocationLinks: make([]LocationLinkFailure, 0),
+	}
+
+	articulation, bridges := f.sites.cuts()
+
+	// routers and router links only cutting off routers at unknown locations disconnect no locations, so are left out
+	for _, id := range articulationRouters(articulation) {
+		if failure := f.router(id); len(failure.Split) > 0 {
+			points.Routers = append(points.Routers, failure)
+		}
+	}
+
+	for _, pair := range bridgeLinks(bridges) {
+		if failure := f.routerLink(pair.A, pair.B); len(failure.Split) > 0 {
+			points.RouterLinks = append(points.RouterLinks, failure)
+		}
+	}
+
+	articulation, bridges = f.locations.cuts()
+
+	for _, id := range articulationLocations(articulation) {
+		points.Locations = append(points.Locations, f.location(id))
+	}
+
+	for _, link := range f.graph.bridgeLocationLinks(bridges) {
+		points.LocationLinks = append(points.LocationLinks, f.locationLink(link.SourceID, link.DestinationID))
+	}
+
+	return points
+}
+
+// Router returns what the router failing would disconnect, an error is returned when it isn't in the graph
+func (f *Failures) Router(id int) (RouterFailure, error) {
+	if _, ok := f.graph.routers[id]; !ok {
+		return RouterFailure{}, fmt.Errorf("router %d not found", id)
+	}
+
+	return f.router(id), nil
+}
+
+// RouterLink returns what the link between two routers failing would disconnect, an error is returned when they
+// aren't linked
+func (f *Failures) RouterLink(a, b int) (RouterLinkFailure, error) {
+	if _, ok := f.graph.adjacency[a][b]; !ok {
+		return RouterLinkFailure{}, fmt.Errorf("routers %d and %d aren't linked", a, b)
+	}
+
+	return f.routerLink(a, b), nil
+}
+
+// Location returns what the location failing would disconnect, an error is returned when it isn't in the graph
+func (f *Failures) Location(id int) (LocationFailure, error) {
+	if _, ok := f.graph.locations[id]; !ok {
+		return LocationFailure{}, fmt.Errorf("location %d not found", id)
+	}
+
+	return f.location(id), nil
+}
+
+// LocationLink returns what the link between two locations failing would disconnect, an error is returned when they
+// aren't linked
+func (f *Failures) LocationLink(a, b int) (LocationLinkFailure, error) {
+	if !hasNeighbour(f.locations[node{id: a}], node{id: b}) {
+		return LocationLinkFailure{}, fmt.Errorf("locations %d and %d aren't linked", a, b)
+	}
+
+	return f.locationLink(a, b), nil
+}
+
+func (f *Failures) router(id int) RouterFailure {
+	failed := node{router: true, id: id}
+
+	return RouterFailure{
+		Router: f.graph.routers[id],
+		Split:  f.lookup(f.sites.split(f.sites[failed], failed, [2]node{})),
+	}
+}
+
+func (f *Failures) routerLink(a, b int) RouterLinkFailure {
+	failed := [2]node{{router: true, id: a}, {router: true, id: b}}
+
+	return RouterLinkFailure{
+		A:     f.graph.routers[a],
+		B:     f.graph.routers[b],
+		Split: f.lookup(f.sites.split(failed[:], node{}, failed)),
+	}
+}
+
+func (f *Failures) location(id int) LocationFailure {
+	failed := node{id: id}
+
+	return LocationFailure{
+		Location: f.graph.locations[id],
+		Split:    f.lookup(f.locations.split(f.locations[failed], failed, [2]node{})),
+	}
+}
+
+func (f *Failures) locationLink(a, b int) LocationLinkFailure {
+	failed := [2]node{{id: a}, {id: b}}
+
+	return LocationLinkFailure{
+		Source:      f.graph.locations[a],
+		Destination: f.graph.locations[b],
+		Split:       f.lookup(f.locations.split(failed[:], node{}, failed)),
+	}
+}
+
+// lookup looks up the locations of each group of location IDs a failure splits
+func (f *Failures) lookup(split [][]int) [][]api.Location {
+	groups := make([][]api.Location, 0, len(split))
+
+	for _, ids := range split {
+		group := make([]api.Location, 0, len(ids))
+		for _, id := range ids {
+			group = append(group, f.graph.locations[id])
+		}
+
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+func articulationRouters(articulation []node) []int {
+	routers := make([]int, 0)
+	for _, n := range articulation {
+		if n.router {
+			routers = append(routers, n.id)
+		}
+	}
+
+	return routers
+}
+
+func bridgeLinks(bridges [][2]node) []RouterPair {
+	pairs := make([]RouterPair, 0)
+	for _, bridge := range bridges {
+		if bridge[0].router && bridge[1].router {
+			pairs = append(pairs, RouterPair{A: min(bridge[0].id, bridge[1].id), B: max(bridge[0].id, bridge[1].id)})
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].A != pairs[j].A {
+			return pairs[i].A < pairs[j].A
+		}
+		return pairs[i].B < pairs[j].B
+	})
+
+	return pairs
+}
+
+func articulationLocations(articulation []node) []int {
+	locations := make([]int, 0, len(articulation))
+	for _, n := range articulation {
+		locations = append(locations, n.id)
+	}
+
+	return locations
+}
+
+func (g *Graph) bridgeLocationLinks(bridges [][2]node) []LocationLink {
+	isBridge := make(map[RouterPair]struct{}, len(bridges))
+	for _, bridge := range bridges {
+		isBridge[RouterPair{A: min(bridge[0].id, bridge[1].id), B: max(bridge[0].id, bridge[1].id)}] = struct{}{}
+	}
+
+	links := make([]LocationLink, 0, len(bridges))
+	for _, link := range g.LocationLinks() {
+		if _, ok := isBridge[RouterPair{A: link.SourceID, B: link.DestinationID}]; ok {
+			links = append(links, link)
+		}
+	}
+
+	return links
+}
+
+// siteGraph links every router to the location it's at and the routers it's linked to, so routers at the same
+// location are connected through it
+func (g *Graph) siteGraph() undirected {
+	edges := make(map[node]map[node]struct{})
+
+	for id := range g.locations {
+		edges[node{id: id}] = make(map[node]struct{})
+	}
+
+	for id, router := range g.routers {
+		n := node{router: true, id: id}
+		if _, ok := edges[n]; !ok {
+			edges[n] = make(map[node]struct{})
+		}
+
+		if _, ok := g.locations[router.LocationID]; ok {
+			edges[n][node{id: router.LocationID}] = struct{}{}
+			edges[node{id: router.LocationID}][n] = struct{}{}
+		}
+
+		for link := range g.adjacency[id] {
+			edges[n][node{router: true, id: link}] = struct{}{}
+		}
+	}
+
+	return newUndirected(edges)
+}
+
+// locationGraph links the locations by their location links, links to locations which don't exist are dropped
+func (g *Graph) locationGraph() undirected {
+	edges := make(map[node]map[node]struct{})
+
+	for id := range g.locations {
+		edges[node{id: id}] = make(map[node]struct{})
+	}
+
+	for _, link := range g.LocationLinks() {
+		source, destination := node{id: link.SourceID}, node{id: link.DestinationID}
+
+		_, sourceOK := edges[source]
+		_, destinationOK := edges[destination]
+		if !sourceOK || !destinationOK {
+			continue
+		}
+
+		edges[source][destination] = struct{}{}
+		edges[destination][source] = struct{}{}
+	}
+
+	return newUndirected(edges)
+}
+
+// newUndirected orders the neighbours of every node so traversals are deterministic
+func newUndirected(edges map[node]map[node]struct{}) undirected {
+	u := make(undirected, len(edges))
+
+	for n, neighbours := range edges {
+		ordered := make([]node, 0, len(neighbours))
+		for neighbour := range neighbours {
+			ordered = append(ordered, neighbour)
+		}
+
+		sortNodes(ordered)
+		u[n] = ordered
+	}
+
+	return u
+}
+
+// cuts finds the articulation nodes and bridges with Tarjan's algorithm, articulation nodes are ordered locations first
+// then by ID
+func (u undirected) cuts() ([]node, [][2]node) {
+	var (
+		counter      int
+		index        = make(map[node]int, len(u))
+		low          = make(map[node]int, len(u))
+		articulation = make(map[node]struct{})
+		bridges      = make([][2]node, 0)
+	)
+
+	var visit func(n node, parent *node)
+	visit = func(n node, parent *node) {
+		counter++
+		index[n], low[n] = counter, counter
+
+		children := 0
+		for _, m := range u[n] {
+			if parent != nil && m == *parent {
+				continue
+			}
+
+			if _, ok := index[m]; ok {
+				low[n] = min(low[n], index[m])
+				continue
+			}
+
+			children++
+			visit(m, &n)
+			low[n] = min(low[n], low[m])
+
+			if parent != nil && low[m] >= index[n] {
+				articulation[n] = struct{}{}
+			}
+
+			if low[m] > index[n] {
+				bridges = append(bridges, [2]node{n, m})
+			}
+		}
+
+		// the root of the depth first search only separates its subtrees when it has more than one
+		if parent == nil && children > 1 {
+			articulation[n] = struct{}{}
+		}
+	}
+
+	for _, n := range u.nodes() {
+		if _, ok := index[n]; !ok {
+			visit(n, nil)
+		}
+	}
+
+	nodes := make([]node, 0, len(articulation))
+	for n := range articulation {
+		nodes = append(nodes, n)
+	}
+
+	sortNodes(nodes)
+
+	return nodes, bridges
+}
+
+// split traverses from the start nodes without the failed node or edge, returning the location IDs of each part left
+// largest first, ties ordered by lowest ID. Parts without locations are dropped and none are returned when the
+// locations are left in a single part
+func (u undirected) split(start []node, failedNode node, failedEdge [2]node) [][]int {
+	failed := func(a, b node) bool {
+		return (a == failedEdge[0] && b == failedEdge[1]) || (a == failedEdge[1] && b == failedEdge[0])
+	}
+
+	visited := map[node]struct{}{failedNode: {}}
+	parts := make([][]int, 0)
+
+	for _, n := range start {
+		if _, ok := visited[n]; ok {
+			continue
+		}
+
+		visited[n] = struct{}{}
+
+		part := make([]int, 0)
+		queue := []node{n}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+
+			if !current.router {
+				part = append(part, current.id)
+			}
+
+			for _, next := range u[current] {
+				if _, ok := visited[next]; ok || failed(current, next) {
+					continue
+				}
+
+				visited[next] = struct{}{}
+				queue = append(queue, next)
+			}
+		}
+
+		if len(part) > 0 {
+			sort.Ints(part)
+			parts = append(parts, part)
+		}
+	}
+
+	if len(parts) < 2 {
+		return make([][]int, 0)
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		if len(parts[i]) != len(parts[j]) {
+			return len(parts[i]) > len(parts[j])
+		}
+		return parts[i][0] < parts[j][0]
+	})
+
+	return parts
+}
+
+// nodes returns every node ordered locations first then by ID
+func (u undirected) nodes() []node {
+	nodes := make([]node, 0, len(u))
+	for n := range u {
+		nodes = append(nodes, n)
+	}
+
+	sortNodes(nodes)
+
+	return nodes
+}
+
+func sortNodes(nodes []node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].router != nodes[j].router {
+			return !nodes[i].router
+		}
+		return nodes[i].id < nodes[j].id
+	})
+}
+
+func hasNeighbour(neighbours []node, n node) bool {
+	for _, neighbour := range neighbours {
+		if neighbour == n {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,226 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+)
+
+func TestGraph_SinglePointsOfFailure(t *testing.T) {
+	g, err := New(sampleData())
+	assert.NoError(t, err)
+
+	assert.Equal(t, []int{8, 9, 10, 11, 14, 15}, g.ArticulationRouters())
+	assert.Equal(t, []RouterPair{{A: 8, B: 11}, {A: 9, B: 14}, {A: 9, B: 15}, {A: 10, B: 14}}, g.BridgeLinks())
+	assert.Equal(t, []int{4, 8}, g.ArticulationLocations())
+	assert.Equal(t, g.LocationLinks(), g.BridgeLocationLinks())
+}
+
+// splitIDs returns the location IDs of each group of a split
+func splitIDs(split [][]api.Location) [][]int {
+	ids := make([][]int, 0, len(split))
+	for _, group := range split {
+		groupIDs := make([]int, 0, len(group))
+		for _, location := range group {
+			groupIDs = append(groupIDs, location.ID)
+		}
+
+		ids = append(ids, groupIDs)
+	}
+
+	return ids
+}
+
+func TestFailures(t *testing.T) {
+	g, err := New(sampleData())
+	assert.NoError(t, err)
+
+	failures := g.Failures()
+
+	split := func(split [][]api.Location, err error) ([][]int, error) {
+		return splitIDs(split), err
+	}
+
+	tests := []struct {
+		name    string
+		failure func() ([][]int, error)
+		want    [][]int
+		wantErr string
+	}{
+		{
+			name:    "router linking three parts",
+			failure: func() ([][]int, error) { f, err := failures.Router(14); return split(f.Split, err) },
+			want:    [][]int{{7, 8}, {4}, {5}},
+		},
+		{
+			name:    "only router at a location cuts the location off",
+			failure: func() ([][]int, error) { f, err := failures.Router(10); return split(f.Split, err) },
+			want:    [][]int{{4, 7, 8}, {5}},
+		},
+		{
+			name:    "router whose location is connected through another router",
+			failure: func() ([][]int, error) { f, err := failures.Router(3); return split(f.Split, err) },
+			want:    [][]int{},
+		},
+		{
+			name:    "router which doesn't exist",
+			failure: func() ([][]int, error) { f, err := failures.Router(99); return split(f.Split, err) },
+			wantErr: "router 99 not found",
+		},
+		{
+			name:    "router link",
+			failure: func() ([][]int, error) { f, err := failures.RouterLink(15, 9); return split(f.Split, err) },
+			want:    [][]int{{4, 5, 8}, {7}},
+		},
+		{
+			name:    "router link between routers at the same location",
+			failure: func() ([][]int, error) { f, err := failures.RouterLink(4, 14); return split(f.Split, err) },
+			want:    [][]int{},
+		},
+		{
+			name:    "router link which doesn't exist",
+			failure: func() ([][]int, error) { f, err := failures.RouterLink(1, 2); return split(f.Split, err) },
+			wantErr: "routers 1 and 2 aren't linked",
+		},
+		{
+			name:    "location",
+			failure: func() ([][]int, error) { f, err := failures.Location(8); return split(f.Split, err) },
+			want:    [][]int{{4, 5}, {7}},
+		},
+		{
+			name:    "location which doesn't exist",
+			failure: func() ([][]int, error) { f, err := failures.Location(99); return split(f.Split, err) },
+			wantErr: "location 99 not found",
+		},
+		{
+			name:    "location link",
+			failure: func() ([][]int, error) { f, err := failures.LocationLink(6, 2); return split(f.Split, err) },
+			want:    [][]int{{2}, {6}},
+		},
+		{
+			name:    "location link which doesn't exist",
+			failure: func() ([][]int, error) { f, err := failures.LocationLink(5, 7); return split(f.Split, err) },
+			wantErr: "locations 5 and 7 aren't linked",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.failure()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFailures_SinglePoints(t *testing.T) {
+	data := sampleData()
+
+	g, err := New(data)
+	assert.NoError(t, err)
+
+	points := g.Failures().SinglePoints()
+
+	routers := make([]int, 0, len(points.Routers))
+	for _, failure := range points.Routers {
+		routers = append(routers, failure.Router.ID)
+		assert.NotEmpty(t, failure.Split, "router %d", failure.Router.ID)
+	}
+	assert.Equal(t, g.ArticulationRouters(), routers)
+
+	routerLinks := make([]RouterPair, 0, len(points.RouterLinks))
+	for _, failure := range points.RouterLinks {
+		routerLinks = append(routerLinks, RouterPair{A: failure.A.ID, B: failure.B.ID})
+	}
+	assert.Equal(t, g.BridgeLinks(), routerLinks)
+
+	assert.Equal(t, []LocationFailure{
+		{Location: data.Locations[3], Split: [][]api.Location{{data.Locations[6], data.Locations[7]}, {data.Locations[4]}}},
+		{Location: data.Locations[7], Split: [][]api.Location{{data.Locations[3], data.Locations[4]}, {data.Locations[6]}}},
+	}, points.Locations)
+
+	assert.Equal(t, []LocationLinkFailure{
+		{Source: data.Locations[1], Destination: data.Locations[5], Split: [][]api.Location{{data.Locations[1]}, {data.Locations[5]}}},
+		{Source: data.Locations[3], Destination: data.Locations[4], Split: [][]api.Location{{data.Locations[3], data.Locations[6], data.Locations[7]}, {data.Locations[4]}}},
+		{Source: data.Locations[3], Destination: data.Locations[7], Split: [][]api.Location{{data.Locations[3], data.Locations[4]}, {data.Locations[6], data.Locations[7]}}},
+		{Source: data.Locations[6], Destination: data.Locations[7], Split: [][]api.Location{{data.Locations[3], data.Locations[4], data.Locations[7]}, {data.Locations[6]}}},
+	}, points.LocationLinks)
+}
+
+func TestFailures_SinglePoints_UnknownLocation(t *testing.T) {
+	// router 3 is at a location which doesn't exist and hangs off router 2, location 1 staying connected through router 1
+	g, err := New(&api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, LocationID: 1, RouterLinks: []int{2}},
+			{ID: 2, LocationID: 1, RouterLinks: []int{1, 3}},
+			{ID: 3, LocationID: 99, RouterLinks: []int{2}},
+		},
+		Locations: []api.Location{{ID: 1}},
+	})
+	assert.NoError(t, err)
+
+	// router 2 and its link to router 3 only cut router 3 off, so disconnect no locations
+	assert.Equal(t, []int{2}, g.ArticulationRouters())
+	assert.Equal(t, []RouterPair{{A: 2, B: 3}}, g.BridgeLinks())
+
+	assert.Equal(t, SinglePoints{
+		Routers:       []RouterFailure{},
+		RouterLinks:   []RouterLinkFailure{},
+		Locations:     []LocationFailure{},
+		LocationLinks: []LocationLinkFailure{},
+	}, g.Failures().SinglePoints())
+}
+
+func TestFailures_SinglePoints_Empty(t *testing.T) {
+	g, err := New(&api.RouterLocationData{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, SinglePoints{
+		Routers:       []RouterFailure{},
+		RouterLinks:   []RouterLinkFailure{},
+		Locations:     []LocationFailure{},
+		LocationLinks: []LocationLinkFailure{},
+	}, g.Failures().SinglePoints())
+}
+
+func TestGraph_SinglePointsOfFailure_Cycle(t *testing.T) {
+	// locations 1, 2 and 3 are linked in a ring with location 4 hanging off location 3, each the site of one router
+	g, err := New(&api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, LocationID: 1, RouterLinks: []int{2, 3}},
+			{ID: 2, LocationID: 2, RouterLinks: []int{1, 3}},
+			{ID: 3, LocationID: 3, RouterLinks: []int{1, 2, 4}},
+			{ID: 4, LocationID: 4, RouterLinks: []int{3}},
+		},
+		Locations: []api.Location{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}},
+	})
+	assert.NoError(t, err)
+
+	// every router is the only one at its location so its failure cuts the location off
+	assert.Equal(t, []int{1, 2, 3, 4}, g.ArticulationRouters())
+
+	failures := g.Failures()
+
+	failure, err := failures.Router(1)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int{{2, 3, 4}, {1}}, splitIDs(failure.Split))
+
+	failure, err = failures.Router(4)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int{{1, 2, 3}, {4}}, splitIDs(failure.Split))
+
+	assert.Equal(t, []RouterPair{{A: 3, B: 4}}, g.BridgeLinks())
+	assert.Equal(t, []int{3}, g.ArticulationLocations())
+	assert.Equal(t, []LocationLink{{SourceID: 3, DestinationID: 4, Routers: []RouterPair{{A: 3, B: 4}}}}, g.BridgeLocationLinks())
+
+	// the ring stays connected without any one of its links
+	linkFailure, err := failures.RouterLink(1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int{}, splitIDs(linkFailure.Split))
+}
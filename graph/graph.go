@@ -0,0 +1,358 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"router-location-connecter/api"
+)
+
+// Graph is an undirected router graph built from router location data
+type Graph struct {
+	routers   map[int]api.Router
+	locations map[int]api.Location
+	// declared counts router links as listed in the data, keyed by the router declaring them
+	declared map[int]map[int]int
+	// adjacency holds the undirected router links accepted into the graph
+	adjacency map[int]map[int]struct{}
+	policy    LinkPolicy
+	// duplicates are the IDs of routers added more than once, failing the build
+	duplicates map[int]struct{}
+}
+
+// RouterPair is an undirected link between two routers
+type RouterPair struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+// LocationLink is an undirected link between two different locations, SourceID is always the lower of the two IDs
+type LocationLink struct {
+	SourceID      int
+	DestinationID int
+	// Routers lists the router links producing this location link, A is always the router at the source location
+	Routers []RouterPair
+}
+
+// New builds the router graph from router location data.
+// One-sided router links are handled according to the link policy, under Strict a *OneSidedLinksError is returned.
+// A *DuplicateRoutersError is returned when router IDs aren't unique
+func New(data *api.RouterLocationData, opts ...Option) (*Graph, error) {
+	b := NewBuilder(opts...)
+
+	for _, location := range data.Locations {
+		b.AddLocation(location)
+	}
+
+	for _, router := range data.Routers {
+		b.AddRouter(router)
+	}
+
+	return b.Build()
+}
+
+// Builder builds a router graph from routers and locations added one at a time, e.g. while streaming them
+type Builder struct {
+	g *Graph
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ api.Handler = (*Builder)(nil)
+
+// NewBuilder initializes an empty graph builder
+func NewBuilder(opts ...Option) *Builder {
+	g := &Graph{
+		routers:    make(map[int]api.Router),
+		locations:  make(map[int]api.Location),
+		declared:   make(map[int]map[int]int),
+		adjacency:  make(map[int]map[int]struct{}),
+		policy:     RequireBoth,
+		duplicates: make(map[int]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return &Builder{g: g}
+}
+
+// AddRouter adds a router and the links it declares. A router with an ID already added replaces the earlier one and its
+// links, and fails the build as which record is kept would otherwise depend on the order of the data
+func (b *Builder) AddRouter(router api.Router) {
+	if _, ok := b.g.routers[router.ID]; ok {
+		b.g.duplicates[router.ID] = struct{}{}
+	}
+
+	b.g.routers[router.ID] = router
+	b.g.declared[router.ID] = make(map[int]int)
+
+	for _, link := range router.RouterLinks {
+		b.g.declared[router.ID][link]++
+	}
+}
+
+// AddLocation adds a location, a location with an ID already added replaces the earlier one
+func (b *Builder) AddLocation(location api.Location) {
+	b.g.locations[location.ID] = location
+}
+
+// HandleRouter adds a router streamed by the api client
+func (b *Builder) HandleRouter(router *api.Router) error {
+	b.AddRouter(*router)
+
+	return nil
+}
+
+// HandleLocation adds a location streamed by the api client
+func (b *Builder) HandleLocation(location *api.Location) error {
+	b.AddLocation(*location)
+
+	return nil
+}
+
+// Build accepts router links into the graph according to the link policy, the builder shouldn't be used afterwards.
+// A *DuplicateRoutersError is returned when any router ID was added more than once, and under Strict a
+// *OneSidedLinksError when any router link is one-sided
+func (b *Builder) Build() (*Graph, error) {
+	g := b.g
+
+	if len(g.duplicates) > 0 {
+		ids := make([]int, 0, len(g.duplicates))
+		for id := range g.duplicates {
+			ids = append(ids, id)
+		}
+
+		sort.Ints(ids)
+
+		return nil, &DuplicateRoutersError{IDs: ids}
+	}
+
+	for id, links := range g.declared {
+		for link := range links {
+			// self-loops don't connect anything and links to unknown routers have nothing to connect to
+			if _, ok := g.routers[link]; !ok || id == link {
+				continue
+			}
+
+			if _, ok := g.declared[link][id]; ok || g.policy == Either {
+				addEdge(g.adjacency, id, link)
+				addEdge(g.adjacency, link, id)
+			}
+		}
+	}
+
+	if g.policy == Strict {
+		if oneSided := g.OneSidedLinks(); len(oneSided) > 0 {
+			return nil, &OneSidedLinksError{Links: oneSided}
+		}
+	}
+
+	return g, nil
+}
+
+// DuplicateRoutersError is returned when building a graph from data holding more than one router with the same ID
+type DuplicateRoutersError struct {
+	// IDs are the duplicated router IDs in ascending order
+	IDs []int
+}
+
+func (e *DuplicateRoutersError) Error() string {
+	ids := make([]string, 0, len(e.IDs))
+	for _, id := range e.IDs {
+		ids = append(ids, strconv.Itoa(id))
+	}
+
+	return fmt.Sprintf("%d duplicate router IDs: %s", len(e.IDs), strings.Join(ids, ", "))
+}
+
+// OneSidedLinks returns every router link which the linked router doesn't declare back, ordered by router ID.
+// Links to routers which don't exist are included
+func (g *Graph) OneSidedLinks() []OneSidedLink {
+	oneSided := make([]OneSidedLink, 0)
+
+	for _, id := range sortedKeys(g.declared) {
+		for _, link := range sortedKeys(g.declared[id]) {
+			if id == link {
+				continue
+			}
+
+			if _, ok := g.declared[link][id]; ok {
+				continue
+			}
+
+			oneSided = append(oneSided, OneSidedLink{
+				RouterID:         id,
+				RouterName:       g.routers[id].Name,
+				LinkedRouterID:   link,
+				LinkedRouterName: g.routers[link].Name,
+			})
+		}
+	}
+
+	return oneSided
+}
+
+// Multiplicity returns how many router links there are between two routers. A link declared by both routers is counted
+// once, as is a link only one of them declares, while a router listing the other more than once declares parallel links
+func (g *Graph) Multiplicity(a, b int) int {
+	return max(g.declared[a][b], g.declared[b][a])
+}
+
+// Router returns the router with the given ID
+func (g *Graph) Router(id int) (api.Router, bool) {
+	router, ok := g.routers[id]
+	return router, ok
+}
+
+// Location returns the location with the given ID
+func (g *Graph) Location(id int) (api.Location, bool) {
+	location, ok := g.locations[id]
+	return location, ok
+}
+
+// Routers returns every router in the graph ordered by ID
+func (g *Graph) Routers() []api.Router {
+	routers := make([]api.Router, 0, len(g.routers))
+	for _, router := range g.routers {
+		routers = append(routers, router)
+	}
+
+	sort.Slice(routers, func(i, j int) bool { return routers[i].ID < routers[j].ID })
+
+	return routers
+}
+
+// Locations returns every location in the graph ordered by ID
+func (g *Graph) Locations() []api.Location {
+	locations := make([]api.Location, 0, len(g.locations))
+	for _, location := range g.locations {
+		locations = append(locations, location)
+	}
+
+	sort.Slice(locations, func(i, j int) bool { return locations[i].ID < locations[j].ID })
+
+	return locations
+}
+
+// Neighbours returns the IDs of the routers linked to the given router ordered by ID
+func (g *Graph) Neighbours(id int) []int {
+	return sortedKeys(g.adjacency[id])
+}
+
+// RouterLinks returns every router link in the graph once, with A being the lower router ID, ordered by A then B
+func (g *Graph) RouterLinks() []RouterPair {
+	pairs := make([]RouterPair, 0)
+
+	for _, id := range sortedKeys(g.adjacency) {
+		for _, link := range sortedKeys(g.adjacency[id]) {
+			if id < link {
+				pairs = append(pairs, RouterPair{A: id, B: link})
+			}
+		}
+	}
+
+	return pairs
+}
+
+// LocationLinks derives the links between locations from the router links, ordered by source then destination ID.
+// Router links between two routers at the same location are dropped
+func (g *Graph) LocationLinks() []LocationLink {
+	byLocations := make(map[RouterPair]*LocationLink)
+
+	for _, pair := range g.RouterLinks() {
+		a, aOK := g.routers[pair.A]
+		b, bOK := g.routers[pair.B]
+		if !aOK || !bOK || a.LocationID == b.LocationID {
+			continue
+		}
+
+		// orientate the link so the source is the lower location ID
+		if a.LocationID > b.LocationID {
+			a, b = b, a
+		}
+
+		key := RouterPair{A: a.LocationID, B: b.LocationID}
+		link, ok := byLocations[key]
+		if !ok {
+			link = &LocationLink{SourceID: a.LocationID, DestinationID: b.LocationID}
+			byLocations[key] = link
+		}
+
+		link.Routers = append(link.Routers, RouterPair{A: a.ID, B: b.ID})
+	}
+
+	links := make([]LocationLink, 0, len(byLocations))
+	for _, link := range byLocations {
+		links = append(links, *link)
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].SourceID != links[j].SourceID {
+			return links[i].SourceID < links[j].SourceID
+		}
+		return links[i].DestinationID < links[j].DestinationID
+	})
+
+	return links
+}
+
+// LinksOf returns the location links of a location, orientated so the location is always the source, ordered by
+// destination ID
+func (g *Graph) LinksOf(locationID int) []LocationLink {
+	links := make([]LocationLink, 0)
+
+	for _, link := range g.LocationLinks() {
+		switch locationID {
+		case link.SourceID:
+			links = append(links, link)
+		case link.DestinationID:
+			reversed := LocationLink{SourceID: link.DestinationID, DestinationID: link.SourceID}
+			for _, pair := range link.Routers {
+				reversed.Routers = append(reversed.Routers, RouterPair{A: pair.B, B: pair.A})
+			}
+
+			links = append(links, reversed)
+		}
+	}
+
+	sort.Slice(links, func(i, j int) bool { return links[i].DestinationID < links[j].DestinationID })
+
+	return links
+}
+
+// RoutersAt returns the routers at a location ordered by ID
+func (g *Graph) RoutersAt(locationID int) []api.Router {
+	routers := make([]api.Router, 0)
+
+	for _, router := range g.Routers() {
+		if router.LocationID == locationID {
+			routers = append(routers, router)
+		}
+	}
+
+	return routers
+}
+
+// addEdge records a directed edge from one router to another
+func addEdge(edges map[int]map[int]struct{}, from, to int) {
+	if _, ok := edges[from]; !ok {
+		edges[from] = make(map[int]struct{})
+	}
+
+	edges[from][to] = struct{}{}
+}
+
+// sortedKeys returns the keys of an int keyed map in ascending order
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Ints(keys)
+
+	return keys
+}
@@ -0,0 +1,266 @@
+package graph
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+)
+
+// sampleData mirrors the data served by the mock server in config/initializerJson.json
+func sampleData() *api.RouterLocationData {
+	return &api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, Name: "citadel-01", LocationID: 1, RouterLinks: []int{1}},
+			{ID: 2, Name: "citadel-02", LocationID: 1, RouterLinks: []int{}},
+			{ID: 3, Name: "core-07", LocationID: 7, RouterLinks: []int{15}},
+			{ID: 4, Name: "hybrid-x022", LocationID: 4, RouterLinks: []int{14}},
+			{ID: 5, Name: "meta-04", LocationID: 3, RouterLinks: []int{6, 7}},
+			{ID: 6, Name: "universal-16", LocationID: 3, RouterLinks: []int{5}},
+			{ID: 7, Name: "prod", LocationID: 3, RouterLinks: []int{5}},
+			{ID: 8, Name: "custprod-01", LocationID: 6, RouterLinks: []int{11}},
+			{ID: 9, Name: "edgesrv-01", LocationID: 8, RouterLinks: []int{14, 15}},
+			{ID: 10, Name: "proxyA", LocationID: 5, RouterLinks: []int{14}},
+			{ID: 11, Name: "proxyB", LocationID: 2, RouterLinks: []int{8}},
+			{ID: 14, Name: "cdn10", LocationID: 4, RouterLinks: []int{4, 9, 10}},
+			{ID: 15, Name: "cdn20", LocationID: 7, RouterLinks: []int{3, 9}},
+		},
+		Locations: []api.Location{
+			{ID: 1, Postcode: "BE12 2ND", Name: "Birmingham Motorcycle Museum"},
+			{ID: 2, Postcode: "BE12 2ND", Name: "Birmingham Hippodrome"},
+			{ID: 3, Postcode: "BE13 1EQ", Name: "Winterbourne House"},
+			{ID: 4, Postcode: "LA10 1DX", Name: "Lancaster Brewery"},
+			{ID: 5, Postcode: "LA10 7QP", Name: "Lancaster University"},
+			{ID: 6, Postcode: "LA10 9FL", Name: "Williamson Park"},
+			{ID: 7, Postcode: "LA11 1DX", Name: "Lancaster Castle"},
+			{ID: 8, Postcode: "LE13 2SW", Name: "Loughborough University"},
+		},
+	}
+}
+
+func TestGraph_LocationLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		data *api.RouterLocationData
+		want []LocationLink
+	}{
+		{
+			name: "derives location links from the sample data",
+			data: sampleData(),
+			want: []LocationLink{
+				{SourceID: 2, DestinationID: 6, Routers: []RouterPair{{A: 11, B: 8}}},
+				{SourceID: 4, DestinationID: 5, Routers: []RouterPair{{A: 14, B: 10}}},
+				{SourceID: 4, DestinationID: 8, Routers: []RouterPair{{A: 14, B: 9}}},
+				{SourceID: 7, DestinationID: 8, Routers: []RouterPair{{A: 15, B: 9}}},
+			},
+		},
+		{
+			name: "drops self-loops, same location links and links only declared by one router",
+			data: &api.RouterLocationData{
+				Routers: []api.Router{
+					{ID: 1, LocationID: 1, RouterLinks: []int{1, 2, 3}},
+					{ID: 2, LocationID: 1, RouterLinks: []int{1}},
+					{ID: 3, LocationID: 2, RouterLinks: []int{}},
+					{ID: 4, LocationID: 3, RouterLinks: []int{99}},
+				},
+			},
+			want: []LocationLink{},
+		},
+		{
+			name: "groups every router link between the same pair of locations",
+			data: &api.RouterLocationData{
+				Routers: []api.Router{
+					{ID: 1, LocationID: 2, RouterLinks: []int{3}},
+					{ID: 2, LocationID: 2, RouterLinks: []int{4}},
+					{ID: 3, LocationID: 1, RouterLinks: []int{1}},
+					{ID: 4, LocationID: 1, RouterLinks: []int{2}},
+				},
+			},
+			want: []LocationLink{
+				{SourceID: 1, DestinationID: 2, Routers: []RouterPair{{A: 3, B: 1}, {A: 4, B: 2}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(tt.data)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, g.LocationLinks())
+		})
+	}
+}
+
+func TestGraph_LocationLinks_OrderIndependent(t *testing.T) {
+	g, err := New(sampleData())
+	assert.NoError(t, err)
+
+	want := g.LocationLinks()
+
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		data := sampleData()
+		rnd.Shuffle(len(data.Routers), func(i, j int) {
+			data.Routers[i], data.Routers[j] = data.Routers[j], data.Routers[i]
+		})
+		rnd.Shuffle(len(data.Locations), func(i, j int) {
+			data.Locations[i], data.Locations[j] = data.Locations[j], data.Locations[i]
+		})
+
+		g, err := New(data)
+		assert.NoError(t, err)
+		assert.Equal(t, want, g.LocationLinks())
+	}
+}
+
+func TestGraph_DuplicateRouters_OrderIndependent(t *testing.T) {
+	first := api.Router{ID: 1, Name: "Router A", LocationID: 1, RouterLinks: []int{2}}
+	second := api.Router{ID: 1, Name: "Router A", LocationID: 3}
+
+	locations := []api.Location{
+		{ID: 1, Postcode: "BE12 2ND", Name: "L1"},
+		{ID: 2, Postcode: "BE12 2ND", Name: "L2"},
+		{ID: 3, Postcode: "BE13 1EQ", Name: "L3"},
+	}
+
+	others := []api.Router{
+		{ID: 2, Name: "Router B", LocationID: 2, RouterLinks: []int{1, 3}},
+		{ID: 3, Name: "Router C", LocationID: 3, RouterLinks: []int{2}},
+	}
+
+	// whichever record of router 1 comes first, the build fails the same way rather than picking one
+	for _, routers := range [][]api.Router{
+		append([]api.Router{first, second}, others...),
+		append([]api.Router{second, first}, others...),
+	} {
+		_, err := New(&api.RouterLocationData{Routers: routers, Locations: locations})
+
+		var duplicateErr *DuplicateRoutersError
+		assert.ErrorAs(t, err, &duplicateErr)
+		assert.Equal(t, []int{1}, duplicateErr.IDs)
+		assert.EqualError(t, err, "1 duplicate router IDs: 1")
+	}
+}
+
+func TestBuilder_AddRouter_ReplacesLinks(t *testing.T) {
+	b := NewBuilder()
+	b.AddRouter(api.Router{ID: 1, Name: "Router A", LocationID: 1, RouterLinks: []int{2}})
+	b.AddRouter(api.Router{ID: 1, Name: "Router A", LocationID: 1})
+
+	// the links of the replaced record aren't kept alongside the router which replaced it
+	assert.Empty(t, b.g.declared[1])
+}
+
+func TestGraph_Neighbours(t *testing.T) {
+	g, err := New(sampleData())
+	assert.NoError(t, err)
+
+	assert.Equal(t, []int{4, 9, 10}, g.Neighbours(14))
+	assert.Equal(t, []int{}, g.Neighbours(1))
+	assert.Equal(t, []int{}, g.Neighbours(2))
+	assert.Equal(t, []RouterPair{
+		{A: 3, B: 15}, {A: 4, B: 14}, {A: 5, B: 6}, {A: 5, B: 7},
+		{A: 8, B: 11}, {A: 9, B: 14}, {A: 9, B: 15}, {A: 10, B: 14},
+	}, g.RouterLinks())
+
+	assert.Equal(t, 1, g.Multiplicity(9, 14))
+	assert.Equal(t, 1, g.Multiplicity(1, 1))
+	assert.Equal(t, 0, g.Multiplicity(1, 2))
+}
+
+func TestGraph_Multiplicity(t *testing.T) {
+	// router 1 lists router 2 twice and router 2 lists it once, router 3 lists router 1 without it listing 3 back
+	g, err := New(&api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, LocationID: 1, RouterLinks: []int{2, 2}},
+			{ID: 2, LocationID: 2, RouterLinks: []int{1}},
+			{ID: 3, LocationID: 3, RouterLinks: []int{1}},
+		},
+	}, WithLinkPolicy(Either))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, g.Multiplicity(1, 2))
+	assert.Equal(t, 2, g.Multiplicity(2, 1))
+	assert.Equal(t, 1, g.Multiplicity(1, 3))
+	assert.Equal(t, 1, g.Multiplicity(3, 1))
+}
+
+func TestGraph_LinksOf(t *testing.T) {
+	g, err := New(sampleData())
+	assert.NoError(t, err)
+
+	assert.Equal(t, []LocationLink{
+		{SourceID: 8, DestinationID: 4, Routers: []RouterPair{{A: 9, B: 14}}},
+		{SourceID: 8, DestinationID: 7, Routers: []RouterPair{{A: 9, B: 15}}},
+	}, g.LinksOf(8))
+	assert.Equal(t, []LocationLink{}, g.LinksOf(1))
+
+	assert.Equal(t, []api.Router{
+		{ID: 5, Name: "meta-04", LocationID: 3, RouterLinks: []int{6, 7}},
+		{ID: 6, Name: "universal-16", LocationID: 3, RouterLinks: []int{5}},
+		{ID: 7, Name: "prod", LocationID: 3, RouterLinks: []int{5}},
+	}, g.RoutersAt(3))
+}
+
+func TestGraph_LinkPolicy(t *testing.T) {
+	// router 1 declares router 2 but router 2 doesn't declare it back, router 3 links to a router which doesn't exist
+	data := &api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, Name: "meta-04", LocationID: 1, RouterLinks: []int{2}},
+			{ID: 2, Name: "universal-16", LocationID: 2, RouterLinks: []int{}},
+			{ID: 3, Name: "prod", LocationID: 3, RouterLinks: []int{3, 99}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		policy    LinkPolicy
+		wantLinks []LocationLink
+		wantErr   string
+	}{
+		{
+			name:      "require both ignores one-sided links",
+			policy:    RequireBoth,
+			wantLinks: []LocationLink{},
+		},
+		{
+			name:   "either treats one-sided links as connections",
+			policy: Either,
+			wantLinks: []LocationLink{
+				{SourceID: 1, DestinationID: 2, Routers: []RouterPair{{A: 1, B: 2}}},
+			},
+		},
+		{
+			name:    "strict fails listing every one-sided link",
+			policy:  Strict,
+			wantErr: "2 one-sided router links: router 1 (meta-04) -> router 2 (universal-16), router 3 (prod) -> router 99 (unknown)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(data, WithLinkPolicy(tt.policy))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+
+				var oneSidedErr *OneSidedLinksError
+				assert.ErrorAs(t, err, &oneSidedErr)
+				assert.Len(t, oneSidedErr.Links, 2)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantLinks, g.LocationLinks())
+		})
+	}
+}
+
+func TestParseLinkPolicy(t *testing.T) {
+	policy, err := ParseLinkPolicy("either")
+	assert.NoError(t, err)
+	assert.Equal(t, Either, policy)
+
+	_, err = ParseLinkPolicy("any")
+	assert.EqualError(t, err, `unknown link policy "any", must be one of require-both|either|strict`)
+}
@@ -0,0 +1,170 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"router-location-connecter/api"
+)
+
+// FindLocation looks up a location by ID, name or postcode. Names and postcodes are matched ignoring case and, for
+// postcodes, spacing. An error is returned when nothing or more than one location matches
+func (g *Graph) FindLocation(query string) (api.Location, error) {
+	if id, err := strconv.Atoi(query); err == nil {
+		if location, ok := g.locations[id]; ok {
+			return location, nil
+		}
+	}
+
+	matches := make([]api.Location, 0)
+	for _, location := range g.Locations() {
+		if strings.TrimSpace(query) == "" {
+			break
+		}
+
+		if strings.EqualFold(location.Name, query) || normalisePostcode(location.Postcode) == normalisePostcode(query) {
+			matches = append(matches, location)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return api.Location{}, fmt.Errorf("no location matches %q", query)
+	case 1:
+		return matches[0], nil
+	default:
+		names := make([]string, 0, len(matches))
+		for _, location := range matches {
+			names = append(names, fmt.Sprintf("%d (%s)", location.ID, location.Name))
+		}
+
+		return api.Location{}, fmt.Errorf("%q matches %d locations: %s", query, len(matches), strings.Join(names, ", "))
+	}
+}
+
+// RouterPaths returns the shortest paths of router IDs from any router at one location to any router at another,
+// ordered by router IDs. At most max paths are returned, every equal-cost path when max is 0, and none when the
+// locations aren't connected
+func (g *Graph) RouterPaths(fromLocationID, toLocationID, max int) [][]int {
+	sources := make([]int, 0)
+	targets := make([]int, 0)
+
+	for _, router := range g.Routers() {
+		if router.LocationID == fromLocationID {
+			sources = append(sources, router.ID)
+		}
+
+		if router.LocationID == toLocationID {
+			targets = append(targets, router.ID)
+		}
+	}
+
+	return shortestPaths(sources, targets, g.Neighbours, max)
+}
+
+// LocationPaths returns the shortest paths of location IDs between two locations over the location links, ordered by
+// location IDs. At most max paths are returned, every equal-cost path when max is 0, and none when the locations
+// aren't connected
+func (g *Graph) LocationPaths(fromLocationID, toLocationID, max int) [][]int {
+	if _, ok := g.locations[fromLocationID]; !ok {
+		return [][]int{}
+	}
+
+	adjacency := make(map[int]map[int]struct{})
+	for _, link := range g.LocationLinks() {
+		addEdge(adjacency, link.SourceID, link.DestinationID)
+		addEdge(adjacency, link.DestinationID, link.SourceID)
+	}
+
+	neighbours := func(id int) []int {
+		return sortedKeys(adjacency[id])
+	}
+
+	return shortestPaths([]int{fromLocationID}, []int{toLocationID}, neighbours, max)
+}
+
+// shortestPaths finds the shortest paths from any source to any target. Distances are measured from the sources and
+// to the targets, so the paths are walked forward only through nodes lying on a shortest path, in ascending ID order
+func shortestPaths(sources, targets []int, neighbours func(int) []int, max int) [][]int {
+	paths := make([][]int, 0)
+	if len(sources) == 0 || len(targets) == 0 {
+		return paths
+	}
+
+	fromSources := distances(sources, neighbours)
+	toTargets := distances(targets, neighbours)
+
+	length := -1
+	for _, target := range targets {
+		if d, ok := fromSources[target]; ok && (length < 0 || d < length) {
+			length = d
+		}
+	}
+
+	if length < 0 {
+		return paths
+	}
+
+	onPath := func(id, hops int) bool {
+		d, ok := toTargets[id]
+		return ok && fromSources[id] == hops && d == length-hops
+	}
+
+	var walk func(path []int) bool
+	walk = func(path []int) bool {
+		hops := len(path) - 1
+		if hops == length {
+			paths = append(paths, append([]int(nil), path...))
+			return max > 0 && len(paths) >= max
+		}
+
+		for _, next := range neighbours(path[hops]) {
+			if onPath(next, hops+1) && walk(append(path, next)) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for _, source := range sources {
+		if onPath(source, 0) && walk([]int{source}) {
+			break
+		}
+	}
+
+	return paths
+}
+
+// distances returns the number of hops from the nearest start to every reachable node, by breadth first search
+func distances(starts []int, neighbours func(int) []int) map[int]int {
+	dist := make(map[int]int, len(starts))
+	queue := make([]int, 0, len(starts))
+
+	for _, start := range starts {
+		if _, ok := dist[start]; !ok {
+			dist[start] = 0
+			queue = append(queue, start)
+		}
+	}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		for _, next := range neighbours(id) {
+			if _, ok := dist[next]; !ok {
+				dist[next] = dist[id] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return dist
+}
+
+// normalisePostcode drops spacing and case so postcodes compare however they're written
+func normalisePostcode(postcode string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(postcode), ""))
+}
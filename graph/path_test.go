@@ -0,0 +1,106 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+)
+
+func TestGraph_FindLocation(t *testing.T) {
+	g, err := New(sampleData())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		query   string
+		wantID  int
+		wantErr string
+	}{
+		{name: "finds a location by ID", query: "7", wantID: 7},
+		{name: "finds a location by name ignoring case", query: "lancaster castle", wantID: 7},
+		{name: "finds a location by postcode ignoring spacing", query: "la111dx", wantID: 7},
+		{name: "fails when nothing matches", query: "Lancaster Priory", wantErr: `no location matches "Lancaster Priory"`},
+		{name: "fails when nothing matches an empty query", query: " ", wantErr: `no location matches " "`},
+		{
+			name:    "fails when a postcode is shared",
+			query:   "BE12 2ND",
+			wantErr: `"BE12 2ND" matches 2 locations: 1 (Birmingham Motorcycle Museum), 2 (Birmingham Hippodrome)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, err := g.FindLocation(tt.query)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantID, location.ID)
+		})
+	}
+}
+
+func TestGraph_Paths(t *testing.T) {
+	g, err := New(sampleData())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		from, to          int
+		max               int
+		wantRouterPaths   [][]int
+		wantLocationPaths [][]int
+	}{
+		{
+			name:              "finds the shortest path across several locations",
+			from:              7,
+			to:                5,
+			max:               1,
+			wantRouterPaths:   [][]int{{15, 9, 14, 10}},
+			wantLocationPaths: [][]int{{7, 8, 4, 5}},
+		},
+		{
+			name:              "finds no path between unconnected locations",
+			from:              7,
+			to:                2,
+			wantRouterPaths:   [][]int{},
+			wantLocationPaths: [][]int{},
+		},
+		{
+			name:              "a location is its own path",
+			from:              3,
+			to:                3,
+			max:               1,
+			wantRouterPaths:   [][]int{{5}},
+			wantLocationPaths: [][]int{{3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantRouterPaths, g.RouterPaths(tt.from, tt.to, tt.max))
+			assert.Equal(t, tt.wantLocationPaths, g.LocationPaths(tt.from, tt.to, tt.max))
+		})
+	}
+}
+
+func TestGraph_Paths_EqualCost(t *testing.T) {
+	// locations 1 and 4 are joined through both location 2 and location 3, each by two routers at location 1
+	g, err := New(&api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, LocationID: 1, RouterLinks: []int{3}},
+			{ID: 2, LocationID: 1, RouterLinks: []int{4}},
+			{ID: 3, LocationID: 2, RouterLinks: []int{1, 5}},
+			{ID: 4, LocationID: 3, RouterLinks: []int{2, 5}},
+			{ID: 5, LocationID: 4, RouterLinks: []int{3, 4}},
+		},
+		Locations: []api.Location{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, [][]int{{1, 3, 5}, {2, 4, 5}}, g.RouterPaths(1, 4, 0))
+	assert.Equal(t, [][]int{{1, 3, 5}}, g.RouterPaths(1, 4, 1))
+	assert.Equal(t, [][]int{{1, 2, 4}, {1, 3, 4}}, g.LocationPaths(1, 4, 0))
+}
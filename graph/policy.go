@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LinkPolicy decides how router links only declared by one of the two routers are handled
+type LinkPolicy string
+
+const (
+	// RequireBoth only accepts a router link when both routers declare it
+	RequireBoth LinkPolicy = "require-both"
+	// Either accepts a router link when either router declares it
+	Either LinkPolicy = "either"
+	// Strict behaves like RequireBoth but fails to build the graph when any one-sided link exists
+	Strict LinkPolicy = "strict"
+)
+
+// ParseLinkPolicy converts a flag value to a LinkPolicy
+func ParseLinkPolicy(policy string) (LinkPolicy, error) {
+	switch p := LinkPolicy(policy); p {
+	case RequireBoth, Either, Strict:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown link policy %q, must be one of %s|%s|%s", policy, RequireBoth, Either, Strict)
+	}
+}
+
+// Option specifies a builder function for configuring a Graph
+type Option func(*Graph)
+
+// WithLinkPolicy sets how one-sided router links are handled, RequireBoth is used by default
+func WithLinkPolicy(policy LinkPolicy) Option {
+	return func(g *Graph) {
+		g.policy = policy
+	}
+}
+
+// OneSidedLink is a router link declared by a router which the linked router doesn't declare back
+type OneSidedLink struct {
+	RouterID   int
+	RouterName string
+	// LinkedRouterName is empty when the linked router doesn't exist
+	LinkedRouterID   int
+	LinkedRouterName string
+}
+
+func (l OneSidedLink) String() string {
+	linkedName := l.LinkedRouterName
+	if linkedName == "" {
+		linkedName = "unknown"
+	}
+
+	return fmt.Sprintf("router %d (%s) -> router %d (%s)", l.RouterID, l.RouterName, l.LinkedRouterID, linkedName)
+}
+
+// OneSidedLinksError is returned when building a graph under the Strict policy and one-sided links exist
+type OneSidedLinksError struct {
+	Links []OneSidedLink
+}
+
+func (e *OneSidedLinksError) Error() string {
+	links := make([]string, 0, len(e.Links))
+	for _, link := range e.Links {
+		links = append(links, link.String())
+	}
+
+	return fmt.Sprintf("%d one-sided router links: %s", len(e.Links), strings.Join(links, ", "))
+}
@@ -0,0 +1,166 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+
+	"router-location-connecter/api"
+)
+
+// Removal lists what's taken out of a graph to simulate it failing, removing a location removes every router at it
+type Removal struct {
+	Routers     []int
+	RouterLinks []RouterPair
+	Locations   []int
+}
+
+// Partition is a component of a graph which a removal split into several parts, parts without locations are left out
+type Partition struct {
+	Component Component   `json:"component"`
+	Parts     []Component `json:"parts"`
+}
+
+// Without returns a copy of the graph with the removal taken out, as if the removed routers, router links and
+// locations were never in the data. Routers, router links and locations which aren't in the graph are returned as an
+// error
+func (g *Graph) Without(removal Removal) (*Graph, error) {
+	var errs []error
+
+	removedRouters := make(map[int]struct{})
+	for _, id := range removal.Routers {
+		if _, ok := g.routers[id]; !ok {
+			errs = append(errs, fmt.Errorf("router %d not found", id))
+		}
+
+		removedRouters[id] = struct{}{}
+	}
+
+	removedLocations := make(map[int]struct{})
+	for _, id := range removal.Locations {
+		if _, ok := g.locations[id]; !ok {
+			errs = append(errs, fmt.Errorf("location %d not found", id))
+		}
+
+		removedLocations[id] = struct{}{}
+	}
+
+	removedLinks := make(map[RouterPair]struct{})
+	for _, pair := range removal.RouterLinks {
+		if _, ok := g.adjacency[pair.A][pair.B]; !ok {
+			errs = append(errs, fmt.Errorf("routers %d and %d aren't linked", pair.A, pair.B))
+		}
+
+		removedLinks[RouterPair{A: pair.A, B: pair.B}] = struct{}{}
+		removedLinks[RouterPair{A: pair.B, B: pair.A}] = struct{}{}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	for id, router := range g.routers {
+		if _, ok := removedLocations[router.LocationID]; ok {
+			removedRouters[id] = struct{}{}
+		}
+	}
+
+	// kept reports whether a router link is left, its routers are kept and it wasn't removed itself
+	kept := func(from, to int) bool {
+		_, fromRemoved := removedRouters[from]
+		_, toRemoved := removedRouters[to]
+		_, linkRemoved := removedLinks[RouterPair{A: from, B: to}]
+
+		return !fromRemoved && !toRemoved && !linkRemoved
+	}
+
+	without := &Graph{
+		routers:   make(map[int]api.Router, len(g.routers)),
+		locations: make(map[int]api.Location, len(g.locations)),
+		declared:  make(map[int]map[int]int, len(g.declared)),
+		adjacency: make(map[int]map[int]struct{}, len(g.adjacency)),
+		policy:    g.policy,
+	}
+
+	for id, location := range g.locations {
+		if _, ok := removedLocations[id]; !ok {
+			without.locations[id] = location
+		}
+	}
+
+	for id, router := range g.routers {
+		if _, ok := removedRouters[id]; ok {
+			continue
+		}
+
+		links := make([]int, 0, len(router.RouterLinks))
+		for _, link := range router.RouterLinks {
+			if kept(id, link) {
+				links = append(links, link)
+			}
+		}
+
+		router.RouterLinks = links
+		without.routers[id] = router
+	}
+
+	for id, links := range g.declared {
+		if _, ok := removedRouters[id]; ok {
+			continue
+		}
+
+		without.declared[id] = make(map[int]int, len(links))
+		for link, count := range links {
+			if kept(id, link) {
+				without.declared[id][link] = count
+			}
+		}
+	}
+
+	for id, links := range g.adjacency {
+		for link := range links {
+			if kept(id, link) {
+				addEdge(without.adjacency, id, link)
+			}
+		}
+	}
+
+	return without, nil
+}
+
+// Partitions compares the components of the graph with those of the graph after a removal, returning the components
+// whose remaining locations are no longer connected, ordered as Components
+func (g *Graph) Partitions(after *Graph) []Partition {
+	partOf := make(map[int]int)
+
+	afterComponents := after.Components()
+	for i, component := range afterComponents {
+		for _, id := range component.Locations {
+			partOf[id] = i
+		}
+	}
+
+	partitions := make([]Partition, 0)
+
+	for _, component := range g.Components() {
+		seen := make(map[int]struct{})
+		for _, id := range component.Locations {
+			if part, ok := partOf[id]; ok {
+				seen[part] = struct{}{}
+			}
+		}
+
+		if len(seen) < 2 {
+			continue
+		}
+
+		// the components after are ordered largest first so ordering by index orders the parts the same way
+		parts := make([]Component, 0, len(seen))
+		for _, part := range sortedKeys(seen) {
+			parts = append(parts, afterComponents[part])
+		}
+
+		partitions = append(partitions, Partition{Component: component, Parts: parts})
+	}
+
+	return partitions
+}
@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraph_Without(t *testing.T) {
+	tests := []struct {
+		name           string
+		removal        Removal
+		wantLinks      []LocationLink
+		wantPartitions []Partition
+		wantErr        string
+	}{
+		{
+			name:    "router",
+			removal: Removal{Routers: []int{10}},
+			wantLinks: []LocationLink{
+				{SourceID: 2, DestinationID: 6, Routers: []RouterPair{{A: 11, B: 8}}},
+				{SourceID: 4, DestinationID: 8, Routers: []RouterPair{{A: 14, B: 9}}},
+				{SourceID: 7, DestinationID: 8, Routers: []RouterPair{{A: 15, B: 9}}},
+			},
+			wantPartitions: []Partition{
+				{
+					Component: Component{Locations: []int{4, 5, 7, 8}, Routers: []int{3, 4, 9, 10, 14, 15}},
+					Parts: []Component{
+						{Locations: []int{4, 7, 8}, Routers: []int{3, 4, 9, 14, 15}},
+						{Locations: []int{5}, Routers: []int{}},
+					},
+				},
+			},
+		},
+		{
+			name:    "router link",
+			removal: Removal{RouterLinks: []RouterPair{{A: 8, B: 11}}},
+			wantLinks: []LocationLink{
+				{SourceID: 4, DestinationID: 5, Routers: []RouterPair{{A: 14, B: 10}}},
+				{SourceID: 4, DestinationID: 8, Routers: []RouterPair{{A: 14, B: 9}}},
+				{SourceID: 7, DestinationID: 8, Routers: []RouterPair{{A: 15, B: 9}}},
+			},
+			wantPartitions: []Partition{
+				{
+					Component: Component{Locations: []int{2, 6}, Routers: []int{8, 11}},
+					Parts: []Component{
+						{Locations: []int{2}, Routers: []int{11}},
+						{Locations: []int{6}, Routers: []int{8}},
+					},
+				},
+			},
+		},
+		{
+			name:    "location removes its routers",
+			removal: Removal{Locations: []int{5, 7}},
+			wantLinks: []LocationLink{
+				{SourceID: 2, DestinationID: 6, Routers: []RouterPair{{A: 11, B: 8}}},
+				{SourceID: 4, DestinationID: 8, Routers: []RouterPair{{A: 14, B: 9}}},
+			},
+			wantPartitions: []Partition{},
+		},
+		{
+			name:    "unknown routers, links and locations",
+			removal: Removal{Routers: []int{99}, RouterLinks: []RouterPair{{A: 1, B: 2}}, Locations: []int{42}},
+			wantErr: "router 99 not found\nlocation 42 not found\nrouters 1 and 2 aren't linked",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(sampleData())
+			assert.NoError(t, err)
+
+			after, err := g.Without(tt.removal)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantLinks, after.LocationLinks())
+			assert.Equal(t, tt.wantPartitions, g.Partitions(after))
+
+			// the graph itself is left as it was
+			assert.Len(t, g.LocationLinks(), 4)
+		})
+	}
+}
+
+func TestGraph_Without_Routers(t *testing.T) {
+	g, err := New(sampleData())
+	assert.NoError(t, err)
+
+	after, err := g.Without(Removal{Routers: []int{14}, Locations: []int{3}})
+	assert.NoError(t, err)
+
+	_, ok := after.Router(6)
+	assert.False(t, ok)
+
+	router, ok := after.Router(9)
+	assert.True(t, ok)
+	assert.Equal(t, []int{15}, router.RouterLinks)
+	assert.Equal(t, []int{}, after.Neighbours(4))
+	assert.Empty(t, after.OneSidedLinks())
+
+	_, ok = after.Location(3)
+	assert.False(t, ok)
+}
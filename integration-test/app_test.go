@@ -1,6 +1,7 @@
 package integration_test
 
 import (
+	"bytes"
 	"context"
 	"os"
 	"testing"
@@ -11,6 +12,7 @@ import (
 
 	"router-location-connecter/api"
 	"router-location-connecter/app"
+	"router-location-connecter/output"
 	"router-location-connecter/storage"
 )
 
@@ -21,7 +23,7 @@ func Test_app_Process(t *testing.T) {
 		Timestamp().
 		Logger()
 
-	redisHandler, err := storage.New(nil, _redisAddress, _redisPassword)
+	redisHandler, err := storage.New(context.Background(), _redisAddress, _redisPassword)
 	if err != nil {
 		assert.NoError(t, err)
 	}
@@ -43,34 +45,20 @@ func Test_app_Process(t *testing.T) {
 			api:      apiClient,
 			redis:    redisHandler,
 			log:      logger,
-			expected: "[Williamson Park] <-> [Birmingham Hippodrome]\n[Loughborough University] <-> [Lancaster Brewery]\n[Lancaster Brewery] <-> [Lancaster University]\n[Loughborough University] <-> [Lancaster Castle]\n",
+			expected: "[Birmingham Hippodrome] <-> [Williamson Park]\n[Lancaster Brewery] <-> [Lancaster University]\n[Lancaster Brewery] <-> [Loughborough University]\n[Lancaster Castle] <-> [Loughborough University]\n",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
-			// Redirect stdout to buffer using pipe
-			r, w, err := os.Pipe()
-			if err != nil {
-				assert.NoError(t, err)
-			}
-			origStdout := os.Stdout
-			os.Stdout = w
+			var out bytes.Buffer
 
-			a := app.NewApp(tt.api, tt.redis, tt.log)
+			a := app.NewApp(tt.api, tt.redis, output.NewText(&out), tt.log)
 
-			a.Process(ctx)
+			assert.NoError(t, a.Process(ctx))
 
-			buf := make([]byte, 1024)
-			n, err := r.Read(buf)
-			if err != nil {
-				assert.NoError(t, err)
-			}
-
-			os.Stdout = origStdout
-
-			assert.Equal(t, string(buf[:n]), tt.expected)
+			assert.Equal(t, tt.expected, out.String())
 
 		})
 	}
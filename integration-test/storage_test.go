@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -19,7 +20,7 @@ const (
 
 func TestMain(m *testing.M) {
 	ctx := context.Background()
-	redisClient, err := storage.New(nil, _redisAddress, _redisPassword)
+	redisClient, err := storage.New(context.Background(), _redisAddress, _redisPassword)
 	if err != nil {
 		panic(err)
 	}
@@ -27,7 +28,7 @@ func TestMain(m *testing.M) {
 	exitVal := m.Run()
 
 	// Teardown
-	if err := redisClient.FlushAll(ctx); err != nil {
+	if err := redisClient.Purge(ctx); err != nil {
 		panic(err)
 	}
 
@@ -40,7 +41,7 @@ func TestMain(m *testing.M) {
 }
 
 func TestStorage_Add_Retrieve_Router(t *testing.T) {
-	redisHandler, err := storage.New(nil, _redisAddress, _redisPassword)
+	redisHandler, err := storage.New(context.Background(), _redisAddress, _redisPassword)
 	if err != nil {
 		assert.NoError(t, err)
 	}
@@ -84,7 +85,7 @@ func TestStorage_Add_Retrieve_Router(t *testing.T) {
 }
 
 func TestStorage_Add_Retrieve_Location(t *testing.T) {
-	redisHandler, err := storage.New(nil, _redisAddress, _redisPassword)
+	redisHandler, err := storage.New(context.Background(), _redisAddress, _redisPassword)
 	if err != nil {
 		assert.NoError(t, err)
 	}
@@ -128,7 +129,7 @@ func TestStorage_Add_Retrieve_Location(t *testing.T) {
 }
 
 func TestStorage_Add_Retrieve_RouterLocationLinks(t *testing.T) {
-	redisHandler, err := storage.New(nil, _redisAddress, _redisPassword)
+	redisHandler, err := storage.New(context.Background(), _redisAddress, _redisPassword)
 	if err != nil {
 		assert.NoError(t, err)
 	}
@@ -167,3 +168,126 @@ func TestStorage_Add_Retrieve_RouterLocationLinks(t *testing.T) {
 		})
 	}
 }
+
+func TestStorage_AddRouters_AddLocations(t *testing.T) {
+	// a batch size below the number of records writes them over several pipelines
+	redisHandler, err := storage.New(context.Background(), _redisAddress, _redisPassword, storage.WithBatchSize(2))
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	routers := []api.Router{
+		{ID: 21, Name: "Router 21", LocationID: 21, RouterLinks: []int{22}},
+		{ID: 22, Name: "Router 22", LocationID: 22, RouterLinks: []int{21, 23}},
+		{ID: 23, Name: "Router 23", LocationID: 21, RouterLinks: []int{22}},
+	}
+	locations := []api.Location{
+		{ID: 21, Postcode: "LA10 1DX", Name: "Location 21"},
+		{ID: 22, Postcode: "LA10 7QP", Name: "Location 22"},
+	}
+
+	assert.NoError(t, redisHandler.AddRouters(routers))
+	assert.NoError(t, redisHandler.AddLocations(locations))
+
+	for _, want := range routers {
+		router, err := redisHandler.GetRouter(want.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, &want, router)
+	}
+
+	for _, want := range locations {
+		location, err := redisHandler.GetLocation(want.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, &want, location)
+	}
+}
+
+func TestStorage_Stage(t *testing.T) {
+	ctx := context.Background()
+
+	redisHandler, err := storage.New(ctx, _redisAddress, _redisPassword)
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	router := &api.Router{ID: 31, Name: "Router 31", LocationID: 31, RouterLinks: []int{}}
+
+	// a discarded stage is never seen
+	stage, err := redisHandler.(storage.Stager).Stage(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, stage.AddRouter(router))
+	assert.NoError(t, stage.Discard(ctx))
+
+	_, err = redisHandler.GetRouter(router.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	// a committed stage replaces the active data
+	stage, err = redisHandler.(storage.Stager).Stage(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, stage.AddRouter(router))
+
+	_, err = redisHandler.GetRouter(router.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	assert.NoError(t, stage.Commit(ctx))
+
+	got, err := redisHandler.GetRouter(router.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, router, got)
+}
+
+func TestStorage_Active(t *testing.T) {
+	ctx := context.Background()
+
+	redisHandler, err := storage.New(ctx, _redisAddress, _redisPassword)
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	router := &api.Router{ID: 32, Name: "Router 32", LocationID: 32, RouterLinks: []int{}}
+
+	view, err := redisHandler.(storage.Stager).Active(ctx)
+	assert.NoError(t, err)
+
+	stage, err := redisHandler.(storage.Stager).Stage(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, stage.AddRouter(router))
+	assert.NoError(t, stage.Commit(ctx))
+
+	// the view keeps reading the generation active when it was created
+	_, err = view.GetRouter(router.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	got, err := redisHandler.GetRouter(router.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, router, got)
+}
+
+func TestStorage_SaveSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	redisHandler, err := storage.New(ctx, _redisAddress, _redisPassword)
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	snapshot := storage.NewSnapshot(time.Date(2024, 3, 14, 23, 38, 46, 0, time.UTC), api.RouterLocationData{
+		Routers:   []api.Router{{ID: 33, Name: "Router 33", LocationID: 33, RouterLinks: []int{}}},
+		Locations: []api.Location{{ID: 33, Postcode: "LA1 4YW", Name: "Lancaster University"}},
+	})
+
+	assert.NoError(t, redisHandler.SaveSnapshot(snapshot))
+
+	// saving it again conflicts without touching the stored snapshot or its listing
+	changed := *snapshot
+	changed.Routers = 0
+	assert.ErrorIs(t, redisHandler.SaveSnapshot(&changed), storage.ErrConflict)
+
+	infos, err := redisHandler.ListSnapshots()
+	assert.NoError(t, err)
+	assert.Contains(t, infos, snapshot.SnapshotInfo)
+
+	got, err := redisHandler.GetSnapshot(snapshot.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot, got)
+}
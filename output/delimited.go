@@ -0,0 +1,79 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+var _delimitedHeader = []string{
+	"source_location_id", "source_location_name", "source_location_postcode",
+	"destination_location_id", "destination_location_name", "destination_location_postcode",
+	"source_router_id", "source_router_name", "destination_router_id", "destination_router_name",
+}
+
+// Delimited writes location links as delimiter separated rows with a header, one row per router link producing it
+type Delimited struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ Emitter = (*Delimited)(nil)
+
+// NewCSV initializes a comma separated emitter writing to w
+func NewCSV(w io.Writer) *Delimited {
+	return &Delimited{w: csv.NewWriter(w)}
+}
+
+// NewTSV initializes a tab separated emitter writing to w
+func NewTSV(w io.Writer) *Delimited {
+	writer := csv.NewWriter(w)
+	writer.Comma = '\t'
+
+	return &Delimited{w: writer}
+}
+
+func (d *Delimited) Emit(link Link) error {
+	if err := d.writeHeader(); err != nil {
+		return err
+	}
+
+	locations := []string{
+		strconv.Itoa(link.Source.ID), link.Source.Name, link.Source.Postcode,
+		strconv.Itoa(link.Destination.ID), link.Destination.Name, link.Destination.Postcode,
+	}
+
+	for _, pair := range link.Routers {
+		row := append(locations[:len(locations):len(locations)],
+			strconv.Itoa(pair.SourceID), pair.SourceName, strconv.Itoa(pair.DestinationID), pair.DestinationName)
+
+		if err := d.w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Flush writes buffered rows, a run without any links still outputs the header
+func (d *Delimited) Flush() error {
+	if err := d.writeHeader(); err != nil {
+		return err
+	}
+
+	d.headerWritten = false
+	d.w.Flush()
+
+	return d.w.Error()
+}
+
+func (d *Delimited) writeHeader() error {
+	if d.headerWritten {
+		return nil
+	}
+
+	d.headerWritten = true
+
+	return d.w.Write(_delimitedHeader)
+}
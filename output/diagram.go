@@ -0,0 +1,101 @@
+package output
+
+import (
+	"sort"
+
+	"router-location-connecter/api"
+	"router-location-connecter/graph"
+)
+
+// diagram collects the location links of a run so they can be rendered as a whole graph, with the router graph when
+// one is emitted so locations and routers without links are drawn too
+type diagram struct {
+	links        []Link
+	graph        *graph.Graph
+	routerDetail bool
+}
+
+// diagramRouter is a router node drawn within its location's cluster
+type diagramRouter struct {
+	ID   int
+	Name string
+}
+
+// EmitGraph buffers the router graph of a run so every location is drawn, not only those taking part in a link
+func (d *diagram) EmitGraph(g *graph.Graph) error {
+	d.graph = g
+
+	return nil
+}
+
+func (d *diagram) add(link Link) {
+	d.links = append(d.links, link)
+}
+
+// reset clears the collected links and router graph once rendered, returning them to render
+func (d *diagram) reset() diagram {
+	rendered := *d
+	d.links = nil
+	d.graph = nil
+
+	return rendered
+}
+
+// locations returns every location of the router graph and each location taking part in a link once, ordered by ID
+func (d *diagram) locations(links []Link) []api.Location {
+	byID := make(map[int]api.Location)
+	if d.graph != nil {
+		for _, location := range d.graph.Locations() {
+			byID[location.ID] = location
+		}
+	}
+
+	for _, link := range links {
+		byID[link.Source.ID] = link.Source
+		byID[link.Destination.ID] = link.Destination
+	}
+
+	locations := make([]api.Location, 0, len(byID))
+	for _, location := range byID {
+		locations = append(locations, location)
+	}
+
+	sort.Slice(locations, func(i, j int) bool { return locations[i].ID < locations[j].ID })
+
+	return locations
+}
+
+// routers returns every router of the router graph and the routers taking part in a link grouped by their location ID,
+// ordered by router ID
+func (d *diagram) routers(links []Link) map[int][]diagramRouter {
+	seen := make(map[int]struct{})
+	byLocation := make(map[int][]diagramRouter)
+
+	add := func(locationID, id int, name string) {
+		if _, ok := seen[id]; ok {
+			return
+		}
+
+		seen[id] = struct{}{}
+		byLocation[locationID] = append(byLocation[locationID], diagramRouter{ID: id, Name: name})
+	}
+
+	if d.graph != nil {
+		for _, router := range d.graph.Routers() {
+			add(router.LocationID, router.ID, router.Name)
+		}
+	}
+
+	for _, link := range links {
+		for _, pair := range link.Routers {
+			add(link.Source.ID, pair.SourceID, pair.SourceName)
+			add(link.Destination.ID, pair.DestinationID, pair.DestinationName)
+		}
+	}
+
+	for _, routers := range byLocation {
+		sort.Slice(routers, func(i, j int) bool { return routers[i].ID < routers[j].ID })
+	}
+
+	return byLocation
+}
@@ -0,0 +1,229 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+	"router-location-connecter/graph"
+)
+
+func TestDiagramEmitters(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		opts   []Option
+		want   string
+	}{
+		{
+			name:   "dot draws locations as nodes and location links as edges",
+			format: FormatDOT,
+			want: `graph locations {
+  "location_2" [label="Birmingham Hippodrome\nBE12 2ND"];
+  "location_4" [label="Lancaster Brewery\nLA10 1DX"];
+  "location_5" [label="Lancaster University, Bailrigg\nLA10 7QP"];
+  "location_6" [label="Williamson Park\nLA10 9FL"];
+  "location_2" -- "location_6";
+  "location_4" -- "location_5";
+}
+`,
+		},
+		{
+			name:   "dot draws routers clustered by location with router detail",
+			format: FormatDOT,
+			opts:   []Option{WithRouterDetail(true)},
+			want: `graph locations {
+  subgraph "cluster_location_2" {
+    label="Birmingham Hippodrome\nBE12 2ND";
+    "router_11" [label="proxyB"];
+  }
+  subgraph "cluster_location_4" {
+    label="Lancaster Brewery\nLA10 1DX";
+    "router_4" [label="hybrid-x022"];
+    "router_14" [label="cdn10"];
+  }
+  subgraph "cluster_location_5" {
+    label="Lancaster University, Bailrigg\nLA10 7QP";
+    "router_10" [label="proxyA"];
+    "router_12" [label="proxyC"];
+  }
+  subgraph "cluster_location_6" {
+    label="Williamson Park\nLA10 9FL";
+    "router_8" [label="custprod-01"];
+  }
+  "router_11" -- "router_8";
+  "router_14" -- "router_10";
+  "router_4" -- "router_12";
+}
+`,
+		},
+		{
+			name:   "mermaid draws locations as nodes and location links as edges",
+			format: FormatMermaid,
+			want: `graph LR
+  location_2["Birmingham Hippodrome<br/>BE12 2ND"]
+  location_4["Lancaster Brewery<br/>LA10 1DX"]
+  location_5["Lancaster University, Bailrigg<br/>LA10 7QP"]
+  location_6["Williamson Park<br/>LA10 9FL"]
+  location_2 --- location_6
+  location_4 --- location_5
+`,
+		},
+		{
+			name:   "mermaid draws routers in location subgraphs with router detail",
+			format: FormatMermaid,
+			opts:   []Option{WithRouterDetail(true)},
+			want: `graph LR
+  subgraph location_2["Birmingham Hippodrome<br/>BE12 2ND"]
+    router_11["proxyB"]
+  end
+  subgraph location_4["Lancaster Brewery<br/>LA10 1DX"]
+    router_4["hybrid-x022"]
+    router_14["cdn10"]
+  end
+  subgraph location_5["Lancaster University, Bailrigg<br/>LA10 7QP"]
+    router_10["proxyA"]
+    router_12["proxyC"]
+  end
+  subgraph location_6["Williamson Park<br/>LA10 9FL"]
+    router_8["custprod-01"]
+  end
+  router_11 --- router_8
+  router_14 --- router_10
+  router_4 --- router_12
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			emitter, err := New(tt.format, &out, tt.opts...)
+			assert.NoError(t, err)
+
+			for _, link := range testLinks() {
+				assert.NoError(t, emitter.Emit(link))
+			}
+			assert.NoError(t, emitter.Flush())
+
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestDiagramEmitters_Graph(t *testing.T) {
+	// Winterbourne House and its router aren't linked to anything
+	g, err := graph.New(&api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 5, Name: "meta-04", LocationID: 3, RouterLinks: []int{}},
+			{ID: 8, Name: "custprod-01", LocationID: 6, RouterLinks: []int{11}},
+			{ID: 11, Name: "proxyB", LocationID: 2, RouterLinks: []int{8}},
+		},
+		Locations: []api.Location{
+			{ID: 2, Postcode: "BE12 2ND", Name: "Birmingham Hippodrome"},
+			{ID: 3, Postcode: "BE13 1EQ", Name: "Winterbourne House"},
+			{ID: 6, Postcode: "LA10 9FL", Name: "Williamson Park"},
+		},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		format string
+		opts   []Option
+		want   string
+	}{
+		{
+			name:   "dot draws locations without links",
+			format: FormatDOT,
+			want: `graph locations {
+  "location_2" [label="Birmingham Hippodrome\nBE12 2ND"];
+  "location_3" [label="Winterbourne House\nBE13 1EQ"];
+  "location_6" [label="Williamson Park\nLA10 9FL"];
+  "location_2" -- "location_6";
+}
+`,
+		},
+		{
+			name:   "mermaid draws locations and routers without links with router detail",
+			format: FormatMermaid,
+			opts:   []Option{WithRouterDetail(true)},
+			want: `graph LR
+  subgraph location_2["Birmingham Hippodrome<br/>BE12 2ND"]
+    router_11["proxyB"]
+  end
+  subgraph location_3["Winterbourne House<br/>BE13 1EQ"]
+    router_5["meta-04"]
+  end
+  subgraph location_6["Williamson Park<br/>LA10 9FL"]
+    router_8["custprod-01"]
+  end
+  router_11 --- router_8
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			emitter, err := New(tt.format, &out, tt.opts...)
+			assert.NoError(t, err)
+
+			graphEmitter, ok := emitter.(GraphEmitter)
+			assert.True(t, ok)
+			assert.NoError(t, graphEmitter.EmitGraph(g))
+
+			assert.NoError(t, emitter.Emit(testLinks()[0]))
+			assert.NoError(t, emitter.Flush())
+
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func Test_dotID(t *testing.T) {
+	assert.Equal(t, `"Queen's \"Hall\"\nC:\\dir"`, dotID("Queen's \"Hall\"\nC:\\dir"))
+}
+
+func Test_mermaidLabel(t *testing.T) {
+	tests := []struct {
+		name  string
+		label string
+		want  string
+	}{
+		{
+			name:  "escapes quotes and newlines",
+			label: "The \"Hall\"\nAB1 2CD",
+			want:  `"The #quot;Hall#quot;<br/>AB1 2CD"`,
+		},
+		{
+			name:  "escapes html and entity codes",
+			label: "<b>Bar & Grill</b> #1",
+			want:  `"#lt;b#gt;Bar #amp; Grill#lt;/b#gt; #35;1"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mermaidLabel(tt.label))
+		})
+	}
+}
+
+func TestMermaid_EscapesLabels(t *testing.T) {
+	var out bytes.Buffer
+
+	emitter := NewMermaid(&out)
+	assert.NoError(t, emitter.Emit(Link{
+		Source:      api.Location{ID: 1, Postcode: "BE12 2ND", Name: `The "Hall"`},
+		Destination: api.Location{ID: 2, Postcode: "BE13 1EQ", Name: "<script>"},
+	}))
+	assert.NoError(t, emitter.Flush())
+
+	assert.Equal(t, `graph LR
+  location_1["The #quot;Hall#quot;<br/>BE12 2ND"]
+  location_2["#lt;script#gt;<br/>BE13 1EQ"]
+  location_1 --- location_2
+`, out.String())
+}
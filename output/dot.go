@@ -0,0 +1,87 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DOT writes the locations and location links of a run as an undirected Graphviz graph
+type DOT struct {
+	w io.Writer
+	diagram
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ GraphEmitter = (*DOT)(nil)
+
+// NewDOT initializes a Graphviz DOT emitter writing to w
+func NewDOT(w io.Writer, opts ...Option) *DOT {
+	o := newOptions(opts)
+
+	return &DOT{w: w, diagram: diagram{routerDetail: o.routerDetail}}
+}
+
+// Emit buffers the link as the graph can only be written once every link is known
+func (d *DOT) Emit(link Link) error {
+	d.add(link)
+
+	return nil
+}
+
+func (d *DOT) Flush() error {
+	rendered := d.reset()
+	links := rendered.links
+	w := bufio.NewWriter(d.w)
+
+	fmt.Fprintln(w, "graph locations {")
+
+	if d.routerDetail {
+		routers := rendered.routers(links)
+
+		for _, location := range rendered.locations(links) {
+			fmt.Fprintf(w, "  subgraph %s {\n", dotID(fmt.Sprintf("cluster_location_%d", location.ID)))
+			fmt.Fprintf(w, "    label=%s;\n", dotID(location.Name+"\n"+location.Postcode))
+
+			for _, router := range routers[location.ID] {
+				fmt.Fprintf(w, "    %s [label=%s];\n", dotID(routerNode(router.ID)), dotID(router.Name))
+			}
+
+			fmt.Fprintln(w, "  }")
+		}
+
+		for _, link := range links {
+			for _, pair := range link.Routers {
+				fmt.Fprintf(w, "  %s -- %s;\n", dotID(routerNode(pair.SourceID)), dotID(routerNode(pair.DestinationID)))
+			}
+		}
+	} else {
+		for _, location := range rendered.locations(links) {
+			fmt.Fprintf(w, "  %s [label=%s];\n", dotID(locationNode(location.ID)), dotID(location.Name+"\n"+location.Postcode))
+		}
+
+		for _, link := range links {
+			fmt.Fprintf(w, "  %s -- %s;\n", dotID(locationNode(link.Source.ID)), dotID(locationNode(link.Destination.ID)))
+		}
+	}
+
+	fmt.Fprintln(w, "}")
+
+	return w.Flush()
+}
+
+// dotID quotes a string as a DOT identifier, newlines are written as DOT's centred line break
+func dotID(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	return `"` + replacer.Replace(s) + `"`
+}
+
+func locationNode(id int) string {
+	return fmt.Sprintf("location_%d", id)
+}
+
+func routerNode(id int) string {
+	return fmt.Sprintf("router_%d", id)
+}
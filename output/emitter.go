@@ -0,0 +1,70 @@
+package output
+
+import (
+	"fmt"
+	"io"
+
+	"router-location-connecter/api"
+)
+
+// supported output formats
+const (
+	FormatText    = "text"
+	FormatJSON    = "json"
+	FormatNDJSON  = "ndjson"
+	FormatCSV     = "csv"
+	FormatTSV     = "tsv"
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+	FormatGraphML = "graphml"
+	FormatGEXF    = "gexf"
+)
+
+// Emitter is the interface for writing location links to an output
+type Emitter interface {
+	// Emit writes a single location link, implementations may buffer it until Flush is called
+	Emit(link Link) error
+	// Flush writes any buffered location links, completing the output for a run
+	Flush() error
+}
+
+// Link is a link between two locations along with the router links producing it
+type Link struct {
+	Source      api.Location `json:"source"`
+	Destination api.Location `json:"destination"`
+	Routers     []RouterPair `json:"routers"`
+}
+
+// RouterPair is a router link producing a location link, the source router is at the source location
+type RouterPair struct {
+	SourceID        int    `json:"source_router_id"`
+	SourceName      string `json:"source_router_name"`
+	DestinationID   int    `json:"destination_router_id"`
+	DestinationName string `json:"destination_router_name"`
+}
+
+// New initializes the emitter for the given format writing to w, options only apply to the formats supporting them
+func New(format string, w io.Writer, opts ...Option) (Emitter, error) {
+	switch format {
+	case FormatText:
+		return NewText(w), nil
+	case FormatJSON:
+		return NewJSON(w), nil
+	case FormatNDJSON:
+		return NewNDJSON(w), nil
+	case FormatCSV:
+		return NewCSV(w), nil
+	case FormatTSV:
+		return NewTSV(w), nil
+	case FormatDOT:
+		return NewDOT(w, opts...), nil
+	case FormatMermaid:
+		return NewMermaid(w, opts...), nil
+	case FormatGraphML:
+		return NewGraphML(w), nil
+	case FormatGEXF:
+		return NewGEXF(w), nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
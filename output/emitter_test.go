@@ -0,0 +1,119 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+)
+
+func testLinks() []Link {
+	return []Link{
+		{
+			Source:      api.Location{ID: 2, Postcode: "BE12 2ND", Name: "Birmingham Hippodrome"},
+			Destination: api.Location{ID: 6, Postcode: "LA10 9FL", Name: "Williamson Park"},
+			Routers: []RouterPair{
+				{SourceID: 11, SourceName: "proxyB", DestinationID: 8, DestinationName: "custprod-01"},
+			},
+		},
+		{
+			Source:      api.Location{ID: 4, Postcode: "LA10 1DX", Name: "Lancaster Brewery"},
+			Destination: api.Location{ID: 5, Postcode: "LA10 7QP", Name: "Lancaster University, Bailrigg"},
+			Routers: []RouterPair{
+				{SourceID: 14, SourceName: "cdn10", DestinationID: 10, DestinationName: "proxyA"},
+				{SourceID: 4, SourceName: "hybrid-x022", DestinationID: 12, DestinationName: "proxyC"},
+			},
+		},
+	}
+}
+
+func TestEmitters(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		links  []Link
+		want   string
+	}{
+		{
+			name:   "text writes one location link per line",
+			format: FormatText,
+			links:  testLinks(),
+			want:   "[Birmingham Hippodrome] <-> [Williamson Park]\n[Lancaster Brewery] <-> [Lancaster University, Bailrigg]\n",
+		},
+		{
+			name:   "json writes an array of links",
+			format: FormatJSON,
+			links:  testLinks()[:1],
+			want: `[
+  {
+    "source": {
+      "id": 2,
+      "postcode": "BE12 2ND",
+      "name": "Birmingham Hippodrome"
+    },
+    "destination": {
+      "id": 6,
+      "postcode": "LA10 9FL",
+      "name": "Williamson Park"
+    },
+    "routers": [
+      {
+        "source_router_id": 11,
+        "source_router_name": "proxyB",
+        "destination_router_id": 8,
+        "destination_router_name": "custprod-01"
+      }
+    ]
+  }
+]
+`,
+		},
+		{
+			name:   "json writes an empty array when there are no links",
+			format: FormatJSON,
+			want:   "[]\n",
+		},
+		{
+			name:   "ndjson writes one object per line",
+			format: FormatNDJSON,
+			links:  testLinks()[:1],
+			want:   `{"source":{"id":2,"postcode":"BE12 2ND","name":"Birmingham Hippodrome"},"destination":{"id":6,"postcode":"LA10 9FL","name":"Williamson Park"},"routers":[{"source_router_id":11,"source_router_name":"proxyB","destination_router_id":8,"destination_router_name":"custprod-01"}]}` + "\n",
+		},
+		{
+			name:   "csv writes a header and one row per router link",
+			format: FormatCSV,
+			links:  testLinks(),
+			want: "source_location_id,source_location_name,source_location_postcode,destination_location_id,destination_location_name,destination_location_postcode,source_router_id,source_router_name,destination_router_id,destination_router_name\n" +
+				"2,Birmingham Hippodrome,BE12 2ND,6,Williamson Park,LA10 9FL,11,proxyB,8,custprod-01\n" +
+				"4,Lancaster Brewery,LA10 1DX,5,\"Lancaster University, Bailrigg\",LA10 7QP,14,cdn10,10,proxyA\n" +
+				"4,Lancaster Brewery,LA10 1DX,5,\"Lancaster University, Bailrigg\",LA10 7QP,4,hybrid-x022,12,proxyC\n",
+		},
+		{
+			name:   "tsv writes only the header when there are no links",
+			format: FormatTSV,
+			want:   "source_location_id\tsource_location_name\tsource_location_postcode\tdestination_location_id\tdestination_location_name\tdestination_location_postcode\tsource_router_id\tsource_router_name\tdestination_router_id\tdestination_router_name\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			emitter, err := New(tt.format, &out)
+			assert.NoError(t, err)
+
+			for _, link := range tt.links {
+				assert.NoError(t, emitter.Emit(link))
+			}
+			assert.NoError(t, emitter.Flush())
+
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestNew_UnknownFormat(t *testing.T) {
+	_, err := New("xml", &bytes.Buffer{})
+	assert.EqualError(t, err, `unknown output format "xml"`)
+}
@@ -0,0 +1,121 @@
+package output
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+)
+
+// GEXF attribute ids for router nodes
+const (
+	_gexfAttrName         = "0"
+	_gexfAttrLocationID   = "1"
+	_gexfAttrLocationName = "2"
+	_gexfAttrPostcode     = "3"
+)
+
+// GEXF writes the router graph in the GEXF format, e.g. for Gephi
+type GEXF struct {
+	routerGraphEmitter
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ GraphEmitter = (*GEXF)(nil)
+
+// NewGEXF initializes a GEXF emitter writing to w
+func NewGEXF(w io.Writer) *GEXF {
+	return &GEXF{routerGraphEmitter{w: w}}
+}
+
+type gexfDocument struct {
+	XMLName xml.Name  `xml:"gexf"`
+	XMLNS   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	Mode            string         `xml:"mode,attr"`
+	DefaultEdgeType string         `xml:"defaultedgetype,attr"`
+	Attributes      gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode     `xml:"nodes>node"`
+	Edges           []gexfEdge     `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type gexfEdge struct {
+	ID     string `xml:"id,attr"`
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+	Weight int    `xml:"weight,attr"`
+}
+
+// Flush writes the buffered router graph, an empty graph is written when none was emitted
+func (g *GEXF) Flush() error {
+	doc := gexfDocument{
+		XMLNS:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Graph: gexfGraph{
+			Mode:            "static",
+			DefaultEdgeType: "undirected",
+			Attributes: gexfAttributes{
+				Class: "node",
+				Attributes: []gexfAttribute{
+					{ID: _gexfAttrName, Title: "name", Type: "string"},
+					{ID: _gexfAttrLocationID, Title: "location_id", Type: "integer"},
+					{ID: _gexfAttrLocationName, Title: "location_name", Type: "string"},
+					{ID: _gexfAttrPostcode, Title: "postcode", Type: "string"},
+				},
+			},
+		},
+	}
+
+	if g.graph != nil {
+		for _, router := range g.graph.Routers() {
+			location, _ := g.graph.Location(router.LocationID)
+
+			doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{
+				ID:    strconv.Itoa(router.ID),
+				Label: router.Name,
+				AttValues: []gexfAttValue{
+					{For: _gexfAttrName, Value: router.Name},
+					{For: _gexfAttrLocationID, Value: strconv.Itoa(router.LocationID)},
+					{For: _gexfAttrLocationName, Value: location.Name},
+					{For: _gexfAttrPostcode, Value: location.Postcode},
+				},
+			})
+		}
+
+		for i, pair := range g.graph.RouterLinks() {
+			doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{
+				ID:     strconv.Itoa(i),
+				Source: strconv.Itoa(pair.A),
+				Target: strconv.Itoa(pair.B),
+				Weight: g.graph.Multiplicity(pair.A, pair.B),
+			})
+		}
+	}
+
+	return g.writeXML(doc)
+}
@@ -0,0 +1,147 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"router-location-connecter/graph"
+)
+
+// GraphEmitter is implemented by emitters which write the whole router graph rather than only the location links
+type GraphEmitter interface {
+	Emitter
+	// EmitGraph buffers the router graph of a run, to be written on Flush
+	EmitGraph(g *graph.Graph) error
+}
+
+// routerGraphEmitter holds the router graph of a run for the graph exchange formats
+type routerGraphEmitter struct {
+	w     io.Writer
+	graph *graph.Graph
+}
+
+// Emit is a no-op as the router graph already holds every link
+func (r *routerGraphEmitter) Emit(_ Link) error {
+	return nil
+}
+
+func (r *routerGraphEmitter) EmitGraph(g *graph.Graph) error {
+	r.graph = g
+
+	return nil
+}
+
+// writeXML writes the document with an XML header, clearing the buffered graph
+func (r *routerGraphEmitter) writeXML(doc any) error {
+	r.graph = nil
+
+	if _, err := io.WriteString(r.w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(r.w)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(r.w, "\n")
+
+	return err
+}
+
+// GraphML writes the router graph in the GraphML format, e.g. for yEd
+type GraphML struct {
+	routerGraphEmitter
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ GraphEmitter = (*GraphML)(nil)
+
+// NewGraphML initializes a GraphML emitter writing to w
+func NewGraphML(w io.Writer) *GraphML {
+	return &GraphML{routerGraphEmitter{w: w}}
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Flush writes the buffered router graph, an empty graph is written when none was emitted
+func (g *GraphML) Flush() error {
+	doc := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "name", For: "node", AttrName: "name", AttrType: "string"},
+			{ID: "location_id", For: "node", AttrName: "location_id", AttrType: "int"},
+			{ID: "location_name", For: "node", AttrName: "location_name", AttrType: "string"},
+			{ID: "postcode", For: "node", AttrName: "postcode", AttrType: "string"},
+			{ID: "weight", For: "edge", AttrName: "weight", AttrType: "int"},
+		},
+		Graph: graphMLGraph{ID: "routers", EdgeDefault: "undirected"},
+	}
+
+	if g.graph != nil {
+		for _, router := range g.graph.Routers() {
+			location, _ := g.graph.Location(router.LocationID)
+
+			doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+				ID: routerNode(router.ID),
+				Data: []graphMLData{
+					{Key: "name", Value: router.Name},
+					{Key: "location_id", Value: strconv.Itoa(router.LocationID)},
+					{Key: "location_name", Value: location.Name},
+					{Key: "postcode", Value: location.Postcode},
+				},
+			})
+		}
+
+		for i, pair := range g.graph.RouterLinks() {
+			doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+				ID:     fmt.Sprintf("e%d", i),
+				Source: routerNode(pair.A),
+				Target: routerNode(pair.B),
+				Data:   []graphMLData{{Key: "weight", Value: strconv.Itoa(g.graph.Multiplicity(pair.A, pair.B))}},
+			})
+		}
+	}
+
+	return g.writeXML(doc)
+}
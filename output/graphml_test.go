@@ -0,0 +1,140 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+	"router-location-connecter/graph"
+)
+
+func testGraph(t *testing.T) *graph.Graph {
+	g, err := graph.New(&api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 8, Name: "custprod-01", LocationID: 6, RouterLinks: []int{11}},
+			{ID: 11, Name: "proxyB", LocationID: 2, RouterLinks: []int{8}},
+		},
+		Locations: []api.Location{
+			{ID: 2, Postcode: "BE12 2ND", Name: "Birmingham Hippodrome"},
+			{ID: 6, Postcode: "LA10 9FL", Name: "Williamson & Park"},
+		},
+	})
+	assert.NoError(t, err)
+
+	return g
+}
+
+func TestGraphEmitters(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "graphml writes routers as nodes with typed attributes and weighted links",
+			format: FormatGraphML,
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="name" for="node" attr.name="name" attr.type="string"></key>
+  <key id="location_id" for="node" attr.name="location_id" attr.type="int"></key>
+  <key id="location_name" for="node" attr.name="location_name" attr.type="string"></key>
+  <key id="postcode" for="node" attr.name="postcode" attr.type="string"></key>
+  <key id="weight" for="edge" attr.name="weight" attr.type="int"></key>
+  <graph id="routers" edgedefault="undirected">
+    <node id="router_8">
+      <data key="name">custprod-01</data>
+      <data key="location_id">6</data>
+      <data key="location_name">Williamson &amp; Park</data>
+      <data key="postcode">LA10 9FL</data>
+    </node>
+    <node id="router_11">
+      <data key="name">proxyB</data>
+      <data key="location_id">2</data>
+      <data key="location_name">Birmingham Hippodrome</data>
+      <data key="postcode">BE12 2ND</data>
+    </node>
+    <edge id="e0" source="router_8" target="router_11">
+      <data key="weight">1</data>
+    </edge>
+  </graph>
+</graphml>
+`,
+		},
+		{
+			name:   "gexf writes routers as nodes with typed attributes and weighted links",
+			format: FormatGEXF,
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<gexf xmlns="http://gexf.net/1.3" version="1.3">
+  <graph mode="static" defaultedgetype="undirected">
+    <attributes class="node">
+      <attribute id="0" title="name" type="string"></attribute>
+      <attribute id="1" title="location_id" type="integer"></attribute>
+      <attribute id="2" title="location_name" type="string"></attribute>
+      <attribute id="3" title="postcode" type="string"></attribute>
+    </attributes>
+    <nodes>
+      <node id="8" label="custprod-01">
+        <attvalues>
+          <attvalue for="0" value="custprod-01"></attvalue>
+          <attvalue for="1" value="6"></attvalue>
+          <attvalue for="2" value="Williamson &amp; Park"></attvalue>
+          <attvalue for="3" value="LA10 9FL"></attvalue>
+        </attvalues>
+      </node>
+      <node id="11" label="proxyB">
+        <attvalues>
+          <attvalue for="0" value="proxyB"></attvalue>
+          <attvalue for="1" value="2"></attvalue>
+          <attvalue for="2" value="Birmingham Hippodrome"></attvalue>
+          <attvalue for="3" value="BE12 2ND"></attvalue>
+        </attvalues>
+      </node>
+    </nodes>
+    <edges>
+      <edge id="0" source="8" target="11" weight="1"></edge>
+    </edges>
+  </graph>
+</gexf>
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			emitter, err := New(tt.format, &out)
+			assert.NoError(t, err)
+
+			graphEmitter, ok := emitter.(GraphEmitter)
+			assert.True(t, ok)
+
+			assert.NoError(t, graphEmitter.EmitGraph(testGraph(t)))
+			assert.NoError(t, emitter.Flush())
+
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestGraphEmitters_Weight(t *testing.T) {
+	// router 1 lists router 2 twice, which lists it back once, and router 3 is linked to by router 2 alone
+	g, err := graph.New(&api.RouterLocationData{
+		Routers: []api.Router{
+			{ID: 1, LocationID: 1, RouterLinks: []int{2, 2}},
+			{ID: 2, LocationID: 2, RouterLinks: []int{1, 3}},
+			{ID: 3, LocationID: 3, RouterLinks: []int{}},
+		},
+	}, graph.WithLinkPolicy(graph.Either))
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+
+	emitter := NewGEXF(&out)
+	assert.NoError(t, emitter.EmitGraph(g))
+	assert.NoError(t, emitter.Flush())
+
+	assert.Contains(t, out.String(), `<edge id="0" source="1" target="2" weight="2"></edge>`)
+	assert.Contains(t, out.String(), `<edge id="1" source="2" target="3" weight="1"></edge>`)
+}
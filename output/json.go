@@ -0,0 +1,63 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSON writes the location links of a run as a single JSON array
+type JSON struct {
+	w     io.Writer
+	links []Link
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ Emitter = (*JSON)(nil)
+
+// NewJSON initializes a JSON emitter writing to w
+func NewJSON(w io.Writer) *JSON {
+	return &JSON{w: w}
+}
+
+// Emit buffers the link as the array can only be written once every link is known
+func (j *JSON) Emit(link Link) error {
+	j.links = append(j.links, link)
+
+	return nil
+}
+
+func (j *JSON) Flush() error {
+	links := j.links
+	if links == nil {
+		links = []Link{}
+	}
+
+	j.links = nil
+
+	encoder := json.NewEncoder(j.w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(links)
+}
+
+// NDJSON writes each location link as a JSON object on its own line
+type NDJSON struct {
+	encoder *json.Encoder
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ Emitter = (*NDJSON)(nil)
+
+// NewNDJSON initializes a newline delimited JSON emitter writing to w
+func NewNDJSON(w io.Writer) *NDJSON {
+	return &NDJSON{encoder: json.NewEncoder(w)}
+}
+
+func (n *NDJSON) Emit(link Link) error {
+	return n.encoder.Encode(link)
+}
+
+// Flush is a no-op as each line is written as the link is emitted
+func (n *NDJSON) Flush() error {
+	return nil
+}
@@ -0,0 +1,77 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Mermaid writes the locations and location links of a run as a Mermaid flowchart
+type Mermaid struct {
+	w io.Writer
+	diagram
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ GraphEmitter = (*Mermaid)(nil)
+
+// NewMermaid initializes a Mermaid emitter writing to w
+func NewMermaid(w io.Writer, opts ...Option) *Mermaid {
+	o := newOptions(opts)
+
+	return &Mermaid{w: w, diagram: diagram{routerDetail: o.routerDetail}}
+}
+
+// Emit buffers the link as the flowchart can only be written once every link is known
+func (m *Mermaid) Emit(link Link) error {
+	m.add(link)
+
+	return nil
+}
+
+func (m *Mermaid) Flush() error {
+	rendered := m.reset()
+	links := rendered.links
+	w := bufio.NewWriter(m.w)
+
+	fmt.Fprintln(w, "graph LR")
+
+	if m.routerDetail {
+		routers := rendered.routers(links)
+
+		for _, location := range rendered.locations(links) {
+			fmt.Fprintf(w, "  subgraph %s[%s]\n", locationNode(location.ID), mermaidLabel(location.Name+"\n"+location.Postcode))
+
+			for _, router := range routers[location.ID] {
+				fmt.Fprintf(w, "    %s[%s]\n", routerNode(router.ID), mermaidLabel(router.Name))
+			}
+
+			fmt.Fprintln(w, "  end")
+		}
+
+		for _, link := range links {
+			for _, pair := range link.Routers {
+				fmt.Fprintf(w, "  %s --- %s\n", routerNode(pair.SourceID), routerNode(pair.DestinationID))
+			}
+		}
+	} else {
+		for _, location := range rendered.locations(links) {
+			fmt.Fprintf(w, "  %s[%s]\n", locationNode(location.ID), mermaidLabel(location.Name+"\n"+location.Postcode))
+		}
+
+		for _, link := range links {
+			fmt.Fprintf(w, "  %s --- %s\n", locationNode(link.Source.ID), locationNode(link.Destination.ID))
+		}
+	}
+
+	return w.Flush()
+}
+
+// mermaidLabel quotes a node label, escaping quotes, HTML special characters and # as entity codes and newlines as line
+// breaks, so labels can't close the node or inject markup
+func mermaidLabel(s string) string {
+	replacer := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "&", "#amp;", "#", "#35;", "\n", "<br/>")
+
+	return `"` + replacer.Replace(s) + `"`
+}
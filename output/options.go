@@ -0,0 +1,24 @@
+package output
+
+type options struct {
+	routerDetail bool
+}
+
+// Option specifies a builder function for configuring an emitter
+type Option func(*options)
+
+// WithRouterDetail draws routers as nodes grouped by location in diagram formats, rather than just locations
+func WithRouterDetail(detail bool) Option {
+	return func(o *options) {
+		o.routerDetail = detail
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
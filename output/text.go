@@ -0,0 +1,30 @@
+package output
+
+import (
+	"fmt"
+	"io"
+)
+
+// Text writes location links in the format [location name] <-> [location name], one per line
+type Text struct {
+	w io.Writer
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ Emitter = (*Text)(nil)
+
+// NewText initializes a text emitter writing to w
+func NewText(w io.Writer) *Text {
+	return &Text{w: w}
+}
+
+func (t *Text) Emit(link Link) error {
+	_, err := fmt.Fprintf(t.w, "[%s] <-> [%s]\n", link.Source.Name, link.Destination.Name)
+
+	return err
+}
+
+// Flush is a no-op as text output is written as each link is emitted
+func (t *Text) Flush() error {
+	return nil
+}
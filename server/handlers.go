@@ -0,0 +1,201 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"router-location-connecter/api"
+	"router-location-connecter/storage"
+)
+
+// Handler returns the routes of the REST API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /locations", s.listLocations)
+	mux.HandleFunc("GET /locations/{id}/links", s.listLocationLinks)
+	mux.HandleFunc("GET /routers/{id}", s.getRouter)
+	mux.HandleFunc("GET /links", s.listLinks)
+
+	return mux
+}
+
+func (s *Server) listLocations(w http.ResponseWriter, r *http.Request) {
+	data, ok := s.active(w, r)
+	if !ok {
+		return
+	}
+
+	locations, err := data.ListLocations()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, r, locations)
+}
+
+func (s *Server) listLocationLinks(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	data, ok := s.active(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := data.GetLocation(id); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	links, err := data.ListRouterLocationLinks()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	locationLinks := make([]api.RouterLocationLink, 0)
+	for _, link := range links {
+		if link.SourceID == id || link.DestinationID == id {
+			locationLinks = append(locationLinks, link)
+		}
+	}
+
+	writeJSON(w, r, locationLinks)
+}
+
+func (s *Server) getRouter(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	data, ok := s.active(w, r)
+	if !ok {
+		return
+	}
+
+	router, err := data.GetRouter(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, r, router)
+}
+
+func (s *Server) listLinks(w http.ResponseWriter, r *http.Request) {
+	data, ok := s.active(w, r)
+	if !ok {
+		return
+	}
+
+	links, err := data.ListRouterLocationLinks()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, r, links)
+}
+
+// active returns the data set active when the request arrived, read with the request's context, so a response is
+// never made from two data sets when a refresh commits part way through. An error response is written on failure
+func (s *Server) active(w http.ResponseWriter, r *http.Request) (storage.Storage, bool) {
+	data, err := s.storage.Active(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return nil, false
+	}
+
+	return data, true
+}
+
+// pathID parses the id path value, writing a bad request response when it isn't an integer
+func pathID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "id must be an integer")
+		return 0, false
+	}
+
+	return id, true
+}
+
+// writeJSON writes the value as JSON with an ETag of its content, replying not modified when the client's copy matches
+func writeJSON(w http.ResponseWriter, r *http.Request, v any) {
+	var buf bytes.Buffer
+
+	// connections are written as [a] <-> [b] so html escaping would only obscure them
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(v); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	body := buf.Bytes()
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Msg("write response")
+	}
+}
+
+// etagMatches reports whether an If-None-Match header matches the ETag, weak comparison is used as for GET requests
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeError maps storage errors onto status codes
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		writeErrorMessage(w, http.StatusNotFound, "not found")
+	case errors.Is(err, storage.ErrUnavailable):
+		log.Error().Err(err).Msg("storage unavailable")
+		writeErrorMessage(w, http.StatusServiceUnavailable, "storage unavailable")
+	default:
+		log.Error().Err(err).Msg("handle request")
+		writeErrorMessage(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+func writeErrorMessage(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{Error: message})
+}
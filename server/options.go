@@ -0,0 +1,66 @@
+package server
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	_defaultAddress         = ":8080"
+	_defaultRefreshInterval = 5 * time.Minute
+	_defaultShutdownTimeout = 10 * time.Second
+)
+
+type options struct {
+	address         string
+	refreshInterval time.Duration
+	shutdownTimeout time.Duration
+}
+
+// Option specifies a builder function for configuring the server
+type Option func(*options)
+
+// WithAddress sets the address the server listens on, :8080 by default
+func WithAddress(address string) Option {
+	return func(o *options) {
+		o.address = address
+	}
+}
+
+// WithRefreshInterval sets how often router location data is refreshed from the upstream API, every 5 minutes by
+// default. It must be more than 0
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.refreshInterval = interval
+	}
+}
+
+// WithShutdownTimeout sets how long in-flight requests are given to finish on shutdown, 10 seconds by default
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.shutdownTimeout = timeout
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		address:         _defaultAddress,
+		refreshInterval: _defaultRefreshInterval,
+		shutdownTimeout: _defaultShutdownTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// validate checks the options can be served with
+func (o options) validate() error {
+	if o.refreshInterval <= 0 {
+		return fmt.Errorf("invalid refresh interval %s, must be more than 0", o.refreshInterval)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"router-location-connecter/storage"
+)
+
+// Processor refreshes the router location data and location links held in storage, the app's Process satisfies it
+type Processor interface {
+	Process(ctx context.Context) error
+}
+
+// Server serves the location links computed from router location data as a REST API, refreshing the data on an interval
+type Server struct {
+	processor Processor
+	storage   storage.Stager
+	options   options
+	// refreshMu stops refreshes overlapping, requests aren't blocked as the data is staged and swapped in whole
+	refreshMu sync.Mutex
+}
+
+// New initializes a server reading from the storage the processor writes to. Each request reads a single data set
+// from it while refreshes are staged alongside
+func New(processor Processor, s storage.Stager, opts ...Option) *Server {
+	return &Server{
+		processor: processor,
+		storage:   s,
+		options:   newOptions(opts),
+	}
+}
+
+// Refresh replaces the data in storage with the latest router location data, requests carry on against the current
+// data until the new data is committed
+func (s *Server) Refresh(ctx context.Context) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	if err := s.processor.Process(ctx); err != nil {
+		return fmt.Errorf("process router location data: %w", err)
+	}
+
+	return nil
+}
+
+// Run refreshes the data and serves requests until the context is cancelled, then shuts down gracefully letting
+// in-flight requests finish. A failed refresh is logged and retried on the next interval
+func (s *Server) Run(ctx context.Context) error {
+	if err := s.options.validate(); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", s.options.address)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", s.options.address, err)
+	}
+
+	return s.Serve(ctx, listener)
+}
+
+// Serve is Run using an existing listener
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	if err := s.options.validate(); err != nil {
+		return err
+	}
+
+	s.refresh(ctx)
+
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(listener)
+	}()
+
+	log.Info().Str("address", listener.Addr().String()).Msg("serving location links")
+
+	ticker := time.NewTicker(s.options.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-errs:
+			return fmt.Errorf("serve: %w", err)
+		case <-ticker.C:
+			s.refresh(ctx)
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), s.options.shutdownTimeout)
+			defer cancel()
+
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				return fmt.Errorf("shutdown: %w", err)
+			}
+
+			if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("serve: %w", err)
+			}
+
+			return nil
+		}
+	}
+}
+
+// refresh logs rather than returns a failed refresh so the server keeps serving
+func (s *Server) refresh(ctx context.Context) {
+	if err := s.Refresh(ctx); err != nil {
+		log.Error().Err(err).Msg("refresh router location data")
+	}
+}
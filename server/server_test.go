@@ -0,0 +1,264 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+	"router-location-connecter/storage"
+)
+
+// processFunc adapts a function to the Processor interface
+type processFunc func(ctx context.Context) error
+
+func (f processFunc) Process(ctx context.Context) error {
+	return f(ctx)
+}
+
+// storeSampleData returns a processor staging two linked locations and a third without links, as the app does
+func storeSampleData(s storage.Storage) Processor {
+	return processFunc(func(ctx context.Context) error {
+		stage, err := s.(storage.Stager).Stage(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, router := range []api.Router{
+			{ID: 1, Name: "proxyB", LocationID: 2, RouterLinks: []int{2}},
+			{ID: 2, Name: "custprod-01", LocationID: 6, RouterLinks: []int{1}},
+		} {
+			if err := stage.AddRouter(&router); err != nil {
+				return err
+			}
+		}
+
+		for _, location := range []api.Location{
+			{ID: 2, Postcode: "BE12 2ND", Name: "Birmingham Hippodrome"},
+			{ID: 6, Postcode: "LA10 9FL", Name: "Williamson Park"},
+			{ID: 7, Postcode: "LA11 1DX", Name: "Lancaster Castle"},
+		} {
+			if err := stage.AddLocation(&location); err != nil {
+				return err
+			}
+		}
+
+		if err := stage.AddRouterLocationLink(&api.RouterLocationLink{
+			UniqueID:      "Birmingham Hippodrome:Williamson Park",
+			Connection:    "[Birmingham Hippodrome] <-> [Williamson Park]",
+			SourceID:      2,
+			DestinationID: 6,
+		}); err != nil {
+			return err
+		}
+
+		return stage.Commit(ctx)
+	})
+}
+
+func TestServer_Handler(t *testing.T) {
+	const link = `{"unique_id":"Birmingham Hippodrome:Williamson Park","connection":"[Birmingham Hippodrome] <-> [Williamson Park]","source_id":2,"destination_id":6}`
+
+	s := storage.NewMemory().(*storage.Memory)
+	srv := New(storeSampleData(s), s)
+	assert.NoError(t, srv.Refresh(context.Background()))
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "lists locations",
+			path:       "/locations",
+			wantStatus: http.StatusOK,
+			wantBody: `[{"id":2,"postcode":"BE12 2ND","name":"Birmingham Hippodrome"},` +
+				`{"id":6,"postcode":"LA10 9FL","name":"Williamson Park"},` +
+				`{"id":7,"postcode":"LA11 1DX","name":"Lancaster Castle"}]` + "\n",
+		},
+		{
+			name:       "lists the links of a location",
+			path:       "/locations/6/links",
+			wantStatus: http.StatusOK,
+			wantBody:   "[" + link + "]\n",
+		},
+		{
+			name:       "lists no links for a location without any",
+			path:       "/locations/7/links",
+			wantStatus: http.StatusOK,
+			wantBody:   "[]\n",
+		},
+		{
+			name:       "returns not found for the links of an unknown location",
+			path:       "/locations/99/links",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"not found"}` + "\n",
+		},
+		{
+			name:       "gets a router",
+			path:       "/routers/1",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id":1,"name":"proxyB","location_id":2,"router_links":[2]}` + "\n",
+		},
+		{
+			name:       "returns bad request for an id which isn't an integer",
+			path:       "/routers/one",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"id must be an integer"}` + "\n",
+		},
+		{
+			name:       "lists links",
+			path:       "/links",
+			wantStatus: http.StatusOK,
+			wantBody:   "[" + link + "]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantBody, rec.Body.String())
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestServer_ETag(t *testing.T) {
+	s := storage.NewMemory().(*storage.Memory)
+	srv := New(storeSampleData(s), s)
+	assert.NoError(t, srv.Refresh(context.Background()))
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/links", nil))
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	req := httptest.NewRequest(http.MethodGet, "/links", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	// the etag changes with the data
+	assert.NoError(t, s.AddRouterLocationLink(&api.RouterLocationLink{UniqueID: "A:B", SourceID: 7, DestinationID: 8}))
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+}
+
+func TestServer_Refresh(t *testing.T) {
+	s := storage.NewMemory().(*storage.Memory)
+	assert.NoError(t, s.AddRouterLocationLink(&api.RouterLocationLink{UniqueID: "stale"}))
+
+	srv := New(storeSampleData(s), s)
+	assert.NoError(t, srv.Refresh(context.Background()))
+
+	links, err := s.ListRouterLocationLinks()
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+	assert.Equal(t, "Birmingham Hippodrome:Williamson Park", links[0].UniqueID)
+}
+
+func TestServer_Refresh_Staged(t *testing.T) {
+	s := storage.NewMemory().(*storage.Memory)
+	assert.NoError(t, s.AddLocation(&api.Location{ID: 1, Postcode: "BE12 2ND", Name: "Birmingham Motorcycle Museum"}))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	processor := processFunc(func(ctx context.Context) error {
+		close(started)
+		<-release
+
+		return storeSampleData(s).Process(ctx)
+	})
+
+	srv := New(processor, s)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Refresh(context.Background())
+	}()
+
+	// requests made while the refresh is running are served the current data rather than waiting
+	<-started
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/locations", nil))
+	assert.Equal(t, `[{"id":1,"postcode":"BE12 2ND","name":"Birmingham Motorcycle Museum"}]`+"\n", rec.Body.String())
+
+	close(release)
+	assert.NoError(t, <-done)
+
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/locations/1/links", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServer_Serve(t *testing.T) {
+	s := storage.NewMemory().(*storage.Memory)
+
+	refreshed := make(chan struct{}, 10)
+	processor := processFunc(func(ctx context.Context) error {
+		refreshed <- struct{}{}
+		return storeSampleData(s).Process(ctx)
+	})
+
+	srv := New(processor, s, WithRefreshInterval(10*time.Millisecond))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Serve(ctx, listener)
+	}()
+
+	// the data is refreshed on start and then on the interval
+	<-refreshed
+	<-refreshed
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/locations")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NoError(t, resp.Body.Close())
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestServer_Serve_InvalidRefreshInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		wantErr  string
+	}{
+		{name: "rejects a zero interval", interval: 0, wantErr: "invalid refresh interval 0s, must be more than 0"},
+		{name: "rejects a negative interval", interval: -time.Minute, wantErr: "invalid refresh interval -1m0s, must be more than 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := storage.NewMemory().(*storage.Memory)
+			srv := New(storeSampleData(s), s, WithAddress("127.0.0.1:0"), WithRefreshInterval(tt.interval))
+
+			assert.EqualError(t, srv.Run(context.Background()), tt.wantErr)
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			assert.NoError(t, err)
+			defer listener.Close()
+
+			assert.EqualError(t, srv.Serve(context.Background(), listener), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"router-location-connecter/api"
+)
+
+// DefaultBatchSize is how many records are written per pipeline when WithBatchSize isn't given
+const DefaultBatchSize = 500
+
+// ItemError is the failure to write one record of a batch
+type ItemError struct {
+	// Index is the position of the record in the batch
+	Index int
+	Key   string
+	Err   error
+}
+
+func (e ItemError) Error() string {
+	return fmt.Sprintf("key %s: %s", e.Key, e.Err)
+}
+
+func (e ItemError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError is returned by batch writes when any record fails, the records not listed were written
+type BatchError struct {
+	Items []ItemError
+	Total int
+}
+
+func (e *BatchError) Error() string {
+	items := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		items = append(items, item.Error())
+	}
+
+	return fmt.Sprintf("%d of %d records failed: %s", len(e.Items), e.Total, strings.Join(items, ", "))
+}
+
+// Unwrap allows errors.Is to match the error of any failed record, e.g. ErrUnavailable
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Items))
+	for _, item := range e.Items {
+		errs = append(errs, item.Err)
+	}
+
+	return errs
+}
+
+// batchRecord is a record queued for a pipelined write
+type batchRecord struct {
+	key    string
+	member string
+	value  any
+}
+
+func (r *Redis) AddRouters(routers []api.Router) error {
+	g, err := r.active(r.ctx)
+	if err != nil {
+		return err
+	}
+
+	records := make([]batchRecord, 0, len(routers))
+	for _, router := range routers {
+		records = append(records, batchRecord{
+			key:    routerKey(router.ID),
+			member: strconv.Itoa(router.ID),
+			value:  router,
+		})
+	}
+
+	return g.addBatch(_routerIndexKey, records)
+}
+
+func (r *Redis) AddLocations(locations []api.Location) error {
+	g, err := r.active(r.ctx)
+	if err != nil {
+		return err
+	}
+
+	records := make([]batchRecord, 0, len(locations))
+	for _, location := range locations {
+		records = append(records, batchRecord{
+			key:    locationKey(location.ID),
+			member: strconv.Itoa(location.ID),
+			value:  location,
+		})
+	}
+
+	return g.addBatch(_locationIndexKey, records)
+}
+
+// addBatch writes records with JSON.SET, one pipeline per batch size chunk, indexing the records written
+func (r *Redis) addBatch(indexKey string, records []batchRecord) error {
+	batchErr := &BatchError{Total: len(records)}
+
+	for start := 0; start < len(records); start += r.batchSize {
+		end := min(start+r.batchSize, len(records))
+
+		batchErr.Items = append(batchErr.Items, r.addChunk(indexKey, records[start:end], start)...)
+	}
+
+	if len(batchErr.Items) > 0 {
+		return batchErr
+	}
+
+	return nil
+}
+
+// addChunk writes a chunk of records and indexes them in a single pipeline, returning the records which failed.
+// offset is the index of the chunk's first record in the batch
+func (r *Redis) addChunk(indexKey string, records []batchRecord, offset int) []ItemError {
+	failed := make([]ItemError, 0)
+	sets := make(map[int]*goredis.Cmd, len(records))
+	members := make([]any, 0, len(records))
+
+	pipe := r.Client.Pipeline()
+	for i, record := range records {
+		value, err := json.Marshal(record.value)
+		if err != nil {
+			failed = append(failed, ItemError{Index: offset + i, Key: r.recordKey(record.key), Err: err})
+			continue
+		}
+
+		sets[i] = pipe.Do(r.ctx, "JSON.SET", r.recordKey(record.key), ".", value)
+		members = append(members, record.member)
+	}
+
+	if len(sets) == 0 {
+		return failed
+	}
+
+	// listing skips index members without a record, so a member indexed for a failed write is harmless
+	index := pipe.SAdd(r.ctx, r.recordKey(indexKey), members...)
+
+	// the commands are checked one by one below, Exec only returns the first of their errors
+	_, _ = pipe.Exec(r.ctx)
+
+	for i, record := range records {
+		set, ok := sets[i]
+		if !ok {
+			continue
+		}
+
+		err := set.Err()
+		if err == nil {
+			err = index.Err()
+		}
+
+		if err != nil {
+			failed = append(failed, ItemError{Index: offset + i, Key: r.recordKey(record.key), Err: redisCause(err)})
+		}
+	}
+
+	return failed
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+)
+
+func TestBatchError(t *testing.T) {
+	err := &BatchError{
+		Total: 3,
+		Items: []ItemError{
+			{Index: 0, Key: "router_id_1", Err: errors.New("WRONGTYPE")},
+			{Index: 2, Key: "router_id_3", Err: redisCause(errors.New("connection reset by peer"))},
+		},
+	}
+
+	assert.EqualError(t, err, "2 of 3 records failed: key router_id_1: WRONGTYPE, key router_id_3: connection reset by peer")
+	assert.NotErrorIs(t, err, ErrUnavailable)
+
+	err.Items[1].Err = redisCause(errClosed{})
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestMemory_AddRouters_AddLocations(t *testing.T) {
+	m := NewMemory()
+
+	routers := []api.Router{
+		{ID: 2, Name: "universal-16", LocationID: 3, RouterLinks: []int{1}},
+		{ID: 1, Name: "meta-04", LocationID: 3, RouterLinks: []int{2}},
+	}
+	locations := []api.Location{{ID: 3, Postcode: "BE13 1EQ", Name: "Winterbourne House"}}
+
+	assert.NoError(t, m.AddRouters(routers))
+	assert.NoError(t, m.AddLocations(locations))
+
+	// the caller's slices can be reused once written
+	routers[0].RouterLinks[0] = 99
+
+	gotRouters, err := m.ListRouters()
+	assert.NoError(t, err)
+	assert.Equal(t, []api.Router{
+		{ID: 1, Name: "meta-04", LocationID: 3, RouterLinks: []int{2}},
+		{ID: 2, Name: "universal-16", LocationID: 3, RouterLinks: []int{1}},
+	}, gotRouters)
+
+	gotLocations, err := m.ListLocations()
+	assert.NoError(t, err)
+	assert.Equal(t, locations, gotLocations)
+}
+
+// errClosed is a network error as reported for a connection closed mid pipeline
+type errClosed struct{}
+
+func (errClosed) Error() string   { return "use of closed network connection" }
+func (errClosed) Timeout() bool   { return false }
+func (errClosed) Temporary() bool { return false }
@@ -0,0 +1,14 @@
+package storage
+
+import (
+	"errors"
+)
+
+var (
+	// ErrNotFound is returned when the requested key does not exist in storage
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a write clashes with a value already held in storage
+	ErrConflict = errors.New("conflict")
+	// ErrUnavailable is returned when the storage backend can't be reached
+	ErrUnavailable = errors.New("storage unavailable")
+)
@@ -0,0 +1,338 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+
+	"router-location-connecter/api"
+)
+
+// Memory is an in-memory implementation of the Storage interface, safe for concurrent use
+type Memory struct {
+	mu        sync.RWMutex
+	routers   map[int]api.Router
+	locations map[int]api.Location
+	links     map[string]api.RouterLocationLink
+	// shared is set once the routers, locations and links are handed to a view, they're copied before the next write
+	shared    bool
+	snapshots map[string]Snapshot
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ Storage = (*Memory)(nil)
+
+// NewMemory initializes an empty in-memory store
+func NewMemory() Storage {
+	return &Memory{
+		routers:   make(map[int]api.Router),
+		locations: make(map[int]api.Location),
+		links:     make(map[string]api.RouterLocationLink),
+		snapshots: make(map[string]Snapshot),
+	}
+}
+
+func (m *Memory) Purge(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.routers = make(map[int]api.Router)
+	m.locations = make(map[int]api.Location)
+	m.links = make(map[string]api.RouterLocationLink)
+	m.shared = false
+	m.snapshots = make(map[string]Snapshot)
+
+	return nil
+}
+
+func (m *Memory) Clear(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.routers = make(map[int]api.Router)
+	m.locations = make(map[int]api.Location)
+	m.links = make(map[string]api.RouterLocationLink)
+	m.shared = false
+
+	return nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// AddRouterLocationLink only writes the link if it isn't already stored, returning ErrConflict otherwise
+func (m *Memory) AddRouterLocationLink(link *api.RouterLocationLink) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.own()
+
+	if _, ok := m.links[link.UniqueID]; ok {
+		return fmt.Errorf("key %s: %w", link.UniqueID, ErrConflict)
+	}
+
+	m.links[link.UniqueID] = *link
+
+	return nil
+}
+
+func (m *Memory) GetRouterLocationLink(uniqueID string) (*api.RouterLocationLink, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	link, ok := m.links[uniqueID]
+	if !ok {
+		return nil, fmt.Errorf("key %s: %w", uniqueID, ErrNotFound)
+	}
+
+	return &link, nil
+}
+
+func (m *Memory) AddRouter(router *api.Router) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.own()
+
+	// copy links so later changes by the caller don't leak into the store
+	stored := *router
+	stored.RouterLinks = copyLinks(router.RouterLinks)
+	m.routers[router.ID] = stored
+
+	return nil
+}
+
+func (m *Memory) AddRouters(routers []api.Router) error {
+	for _, router := range routers {
+		if err := m.AddRouter(&router); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Memory) GetRouter(id int) (*api.Router, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	router, ok := m.routers[id]
+	if !ok {
+		return nil, fmt.Errorf("key %s: %w", routerKey(id), ErrNotFound)
+	}
+
+	router.RouterLinks = copyLinks(router.RouterLinks)
+
+	return &router, nil
+}
+
+func (m *Memory) AddLocation(location *api.Location) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.own()
+
+	m.locations[location.ID] = *location
+
+	return nil
+}
+
+func (m *Memory) AddLocations(locations []api.Location) error {
+	for _, location := range locations {
+		if err := m.AddLocation(&location); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Memory) GetLocation(id int) (*api.Location, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	location, ok := m.locations[id]
+	if !ok {
+		return nil, fmt.Errorf("key %s: %w", locationKey(id), ErrNotFound)
+	}
+
+	return &location, nil
+}
+
+func (m *Memory) ListRouters() ([]api.Router, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	routers := make([]api.Router, 0, len(m.routers))
+	for _, router := range m.routers {
+		router.RouterLinks = copyLinks(router.RouterLinks)
+		routers = append(routers, router)
+	}
+
+	sort.Slice(routers, func(i, j int) bool { return routers[i].ID < routers[j].ID })
+
+	return routers, nil
+}
+
+func (m *Memory) ListLocations() ([]api.Location, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	locations := make([]api.Location, 0, len(m.locations))
+	for _, location := range m.locations {
+		locations = append(locations, location)
+	}
+
+	sort.Slice(locations, func(i, j int) bool { return locations[i].ID < locations[j].ID })
+
+	return locations, nil
+}
+
+func (m *Memory) ListRouterLocationLinks() ([]api.RouterLocationLink, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	links := make([]api.RouterLocationLink, 0, len(m.links))
+	for _, link := range m.links {
+		links = append(links, link)
+	}
+
+	SortRouterLocationLinks(links)
+
+	return links, nil
+}
+
+func (m *Memory) DeleteRouter(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.own()
+
+	delete(m.routers, id)
+
+	return nil
+}
+
+func (m *Memory) DeleteLocation(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.own()
+
+	delete(m.locations, id)
+
+	return nil
+}
+
+func (m *Memory) DeleteRouterLocationLink(uniqueID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.own()
+
+	delete(m.links, uniqueID)
+
+	return nil
+}
+
+func (m *Memory) SaveSnapshot(snapshot *Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.snapshots[snapshot.ID]; ok {
+		return fmt.Errorf("key %s: %w", snapshotKey(snapshot.ID), ErrConflict)
+	}
+
+	m.snapshots[snapshot.ID] = copySnapshot(*snapshot)
+
+	return nil
+}
+
+func (m *Memory) ListSnapshots() ([]SnapshotInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	infos := make([]SnapshotInfo, 0, len(m.snapshots))
+	for _, snapshot := range m.snapshots {
+		infos = append(infos, snapshot.SnapshotInfo)
+	}
+
+	sortSnapshotInfos(infos)
+
+	return infos, nil
+}
+
+func (m *Memory) GetSnapshot(id string) (*Snapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshot, ok := m.snapshots[id]
+	if !ok {
+		return nil, fmt.Errorf("key %s: %w", snapshotKey(id), ErrNotFound)
+	}
+
+	snapshot = copySnapshot(snapshot)
+
+	return &snapshot, nil
+}
+
+func (m *Memory) PruneSnapshots(retention Retention) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	infos := make([]SnapshotInfo, 0, len(m.snapshots))
+	for _, snapshot := range m.snapshots {
+		infos = append(infos, snapshot.SnapshotInfo)
+	}
+
+	expired := retention.expired(infos)
+	for _, id := range expired {
+		delete(m.snapshots, id)
+	}
+
+	return expired, nil
+}
+
+// own copies the routers, locations and links when they're shared with a view so writing them doesn't change what the
+// view reads, m.mu must be held
+func (m *Memory) own() {
+	if !m.shared {
+		return
+	}
+
+	m.routers = maps.Clone(m.routers)
+	m.locations = maps.Clone(m.locations)
+	m.links = maps.Clone(m.links)
+	m.shared = false
+}
+
+// copySnapshot duplicates a snapshot's data so the stored snapshot stays immutable
+func copySnapshot(snapshot Snapshot) Snapshot {
+	data := api.RouterLocationData{
+		Locations: append([]api.Location(nil), snapshot.Data.Locations...),
+	}
+
+	if snapshot.Data.Routers != nil {
+		data.Routers = make([]api.Router, 0, len(snapshot.Data.Routers))
+		for _, router := range snapshot.Data.Routers {
+			router.RouterLinks = copyLinks(router.RouterLinks)
+			data.Routers = append(data.Routers, router)
+		}
+	}
+
+	snapshot.Data = data
+
+	return snapshot
+}
+
+// copyLinks duplicates a router links slice, keeping the distinction between nil and empty
+func copyLinks(links []int) []int {
+	if links == nil {
+		return nil
+	}
+
+	return append(make([]int, 0, len(links)), links...)
+}
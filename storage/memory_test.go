@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+)
+
+func TestMemory_Add_Retrieve_Router(t *testing.T) {
+	tests := []struct {
+		name    string
+		router  *api.Router
+		getID   int
+		want    *api.Router
+		wantErr error
+	}{
+		{
+			name: "add router to storage and retrieve it",
+			router: &api.Router{
+				ID:          1,
+				Name:        "Router 1",
+				LocationID:  1,
+				RouterLinks: []int{2},
+			},
+			getID: 1,
+			want: &api.Router{
+				ID:          1,
+				Name:        "Router 1",
+				LocationID:  1,
+				RouterLinks: []int{2},
+			},
+		},
+		{
+			name: "returns not found when router does not exist",
+			router: &api.Router{
+				ID:          1,
+				Name:        "Router 1",
+				LocationID:  1,
+				RouterLinks: []int{},
+			},
+			getID:   2,
+			want:    nil,
+			wantErr: ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory()
+
+			assert.NoError(t, m.AddRouter(tt.router))
+
+			got, err := m.GetRouter(tt.getID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMemory_Add_Retrieve_Location(t *testing.T) {
+	m := NewMemory()
+
+	location := &api.Location{
+		ID:       1,
+		Postcode: "AB1 2CD",
+		Name:     "Location A",
+	}
+
+	assert.NoError(t, m.AddLocation(location))
+
+	got, err := m.GetLocation(1)
+	assert.NoError(t, err)
+	assert.Equal(t, location, got)
+
+	_, err = m.GetLocation(2)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemory_Add_Retrieve_RouterLocationLinks(t *testing.T) {
+	m := NewMemory()
+
+	link := &api.RouterLocationLink{
+		UniqueID:   "Location A:Location B",
+		Connection: fmt.Sprintf("[%s] <-> [%s]", "Location A", "Location B"),
+	}
+
+	_, err := m.GetRouterLocationLink(link.UniqueID)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, m.AddRouterLocationLink(link))
+	assert.ErrorIs(t, m.AddRouterLocationLink(link), ErrConflict)
+
+	got, err := m.GetRouterLocationLink(link.UniqueID)
+	assert.NoError(t, err)
+	assert.Equal(t, link, got)
+
+	assert.NoError(t, m.Purge(context.Background()))
+
+	_, err = m.GetRouterLocationLink(link.UniqueID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemory_List_Delete(t *testing.T) {
+	m := NewMemory()
+
+	routers, err := m.ListRouters()
+	assert.NoError(t, err)
+	assert.Equal(t, []api.Router{}, routers)
+
+	assert.NoError(t, m.AddRouter(&api.Router{ID: 2, Name: "citadel-02", LocationID: 1, RouterLinks: []int{}}))
+	assert.NoError(t, m.AddRouter(&api.Router{ID: 1, Name: "citadel-01", LocationID: 1, RouterLinks: []int{1}}))
+	assert.NoError(t, m.AddLocation(&api.Location{ID: 3, Postcode: "BE13 1EQ", Name: "Winterbourne House"}))
+	assert.NoError(t, m.AddLocation(&api.Location{ID: 1, Postcode: "BE12 2ND", Name: "Birmingham Motorcycle Museum"}))
+	assert.NoError(t, m.AddRouterLocationLink(&api.RouterLocationLink{UniqueID: "C:D", SourceID: 3, DestinationID: 4}))
+	assert.NoError(t, m.AddRouterLocationLink(&api.RouterLocationLink{UniqueID: "A:B", SourceID: 1, DestinationID: 2}))
+
+	routers, err = m.ListRouters()
+	assert.NoError(t, err)
+	assert.Equal(t, []api.Router{
+		{ID: 1, Name: "citadel-01", LocationID: 1, RouterLinks: []int{1}},
+		{ID: 2, Name: "citadel-02", LocationID: 1, RouterLinks: []int{}},
+	}, routers)
+
+	locations, err := m.ListLocations()
+	assert.NoError(t, err)
+	assert.Equal(t, []api.Location{
+		{ID: 1, Postcode: "BE12 2ND", Name: "Birmingham Motorcycle Museum"},
+		{ID: 3, Postcode: "BE13 1EQ", Name: "Winterbourne House"},
+	}, locations)
+
+	links, err := m.ListRouterLocationLinks()
+	assert.NoError(t, err)
+	assert.Equal(t, []api.RouterLocationLink{
+		{UniqueID: "A:B", SourceID: 1, DestinationID: 2},
+		{UniqueID: "C:D", SourceID: 3, DestinationID: 4},
+	}, links)
+
+	assert.NoError(t, m.DeleteRouter(2))
+	assert.NoError(t, m.DeleteLocation(3))
+	assert.NoError(t, m.DeleteRouterLocationLink("C:D"))
+	assert.NoError(t, m.DeleteRouterLocationLink("missing"))
+
+	routers, err = m.ListRouters()
+	assert.NoError(t, err)
+	assert.Len(t, routers, 1)
+
+	locations, err = m.ListLocations()
+	assert.NoError(t, err)
+	assert.Len(t, locations, 1)
+
+	links, err = m.ListRouterLocationLinks()
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+}
+
+func TestMemory_ConcurrentAccess(t *testing.T) {
+	m := NewMemory()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+
+			assert.NoError(t, m.AddRouter(&api.Router{ID: id, LocationID: id, RouterLinks: []int{id + 1}}))
+			assert.NoError(t, m.AddLocation(&api.Location{ID: id}))
+
+			_, err := m.GetRouter(id)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -8,6 +8,7 @@ import (
 	context "context"
 	reflect "reflect"
 	api "router-location-connecter/api"
+	storage "router-location-connecter/storage"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -49,6 +50,20 @@ func (mr *MockStorageMockRecorder) AddLocation(location interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLocation", reflect.TypeOf((*MockStorage)(nil).AddLocation), location)
 }
 
+// AddLocations mocks base method.
+func (m *MockStorage) AddLocations(locations []api.Location) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLocations", locations)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddLocations indicates an expected call of AddLocations.
+func (mr *MockStorageMockRecorder) AddLocations(locations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLocations", reflect.TypeOf((*MockStorage)(nil).AddLocations), locations)
+}
+
 // AddRouter mocks base method.
 func (m *MockStorage) AddRouter(router *api.Router) error {
 	m.ctrl.T.Helper()
@@ -77,6 +92,34 @@ func (mr *MockStorageMockRecorder) AddRouterLocationLink(links interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRouterLocationLink", reflect.TypeOf((*MockStorage)(nil).AddRouterLocationLink), links)
 }
 
+// AddRouters mocks base method.
+func (m *MockStorage) AddRouters(routers []api.Router) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRouters", routers)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRouters indicates an expected call of AddRouters.
+func (mr *MockStorageMockRecorder) AddRouters(routers interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRouters", reflect.TypeOf((*MockStorage)(nil).AddRouters), routers)
+}
+
+// Clear mocks base method.
+func (m *MockStorage) Clear(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clear", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Clear indicates an expected call of Clear.
+func (mr *MockStorageMockRecorder) Clear(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clear", reflect.TypeOf((*MockStorage)(nil).Clear), ctx)
+}
+
 // Close mocks base method.
 func (m *MockStorage) Close() error {
 	m.ctrl.T.Helper()
@@ -91,18 +134,46 @@ func (mr *MockStorageMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStorage)(nil).Close))
 }
 
-// FlushAll mocks base method.
-func (m *MockStorage) FlushAll(ctx context.Context) error {
+// DeleteLocation mocks base method.
+func (m *MockStorage) DeleteLocation(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLocation", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLocation indicates an expected call of DeleteLocation.
+func (mr *MockStorageMockRecorder) DeleteLocation(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLocation", reflect.TypeOf((*MockStorage)(nil).DeleteLocation), id)
+}
+
+// DeleteRouter mocks base method.
+func (m *MockStorage) DeleteRouter(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRouter", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRouter indicates an expected call of DeleteRouter.
+func (mr *MockStorageMockRecorder) DeleteRouter(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRouter", reflect.TypeOf((*MockStorage)(nil).DeleteRouter), id)
+}
+
+// DeleteRouterLocationLink mocks base method.
+func (m *MockStorage) DeleteRouterLocationLink(uniqueID string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FlushAll", ctx)
+	ret := m.ctrl.Call(m, "DeleteRouterLocationLink", uniqueID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// FlushAll indicates an expected call of FlushAll.
-func (mr *MockStorageMockRecorder) FlushAll(ctx interface{}) *gomock.Call {
+// DeleteRouterLocationLink indicates an expected call of DeleteRouterLocationLink.
+func (mr *MockStorageMockRecorder) DeleteRouterLocationLink(uniqueID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushAll", reflect.TypeOf((*MockStorage)(nil).FlushAll), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRouterLocationLink", reflect.TypeOf((*MockStorage)(nil).DeleteRouterLocationLink), uniqueID)
 }
 
 // GetLocation mocks base method.
@@ -149,3 +220,121 @@ func (mr *MockStorageMockRecorder) GetRouterLocationLink(uniqueID interface{}) *
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRouterLocationLink", reflect.TypeOf((*MockStorage)(nil).GetRouterLocationLink), uniqueID)
 }
+
+// GetSnapshot mocks base method.
+func (m *MockStorage) GetSnapshot(id string) (*storage.Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSnapshot", id)
+	ret0, _ := ret[0].(*storage.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSnapshot indicates an expected call of GetSnapshot.
+func (mr *MockStorageMockRecorder) GetSnapshot(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshot", reflect.TypeOf((*MockStorage)(nil).GetSnapshot), id)
+}
+
+// ListLocations mocks base method.
+func (m *MockStorage) ListLocations() ([]api.Location, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLocations")
+	ret0, _ := ret[0].([]api.Location)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLocations indicates an expected call of ListLocations.
+func (mr *MockStorageMockRecorder) ListLocations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLocations", reflect.TypeOf((*MockStorage)(nil).ListLocations))
+}
+
+// ListRouterLocationLinks mocks base method.
+func (m *MockStorage) ListRouterLocationLinks() ([]api.RouterLocationLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRouterLocationLinks")
+	ret0, _ := ret[0].([]api.RouterLocationLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRouterLocationLinks indicates an expected call of ListRouterLocationLinks.
+func (mr *MockStorageMockRecorder) ListRouterLocationLinks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRouterLocationLinks", reflect.TypeOf((*MockStorage)(nil).ListRouterLocationLinks))
+}
+
+// ListRouters mocks base method.
+func (m *MockStorage) ListRouters() ([]api.Router, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRouters")
+	ret0, _ := ret[0].([]api.Router)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRouters indicates an expected call of ListRouters.
+func (mr *MockStorageMockRecorder) ListRouters() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRouters", reflect.TypeOf((*MockStorage)(nil).ListRouters))
+}
+
+// ListSnapshots mocks base method.
+func (m *MockStorage) ListSnapshots() ([]storage.SnapshotInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSnapshots")
+	ret0, _ := ret[0].([]storage.SnapshotInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSnapshots indicates an expected call of ListSnapshots.
+func (mr *MockStorageMockRecorder) ListSnapshots() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSnapshots", reflect.TypeOf((*MockStorage)(nil).ListSnapshots))
+}
+
+// PruneSnapshots mocks base method.
+func (m *MockStorage) PruneSnapshots(retention storage.Retention) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PruneSnapshots", retention)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PruneSnapshots indicates an expected call of PruneSnapshots.
+func (mr *MockStorageMockRecorder) PruneSnapshots(retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneSnapshots", reflect.TypeOf((*MockStorage)(nil).PruneSnapshots), retention)
+}
+
+// Purge mocks base method.
+func (m *MockStorage) Purge(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockStorageMockRecorder) Purge(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockStorage)(nil).Purge), ctx)
+}
+
+// SaveSnapshot mocks base method.
+func (m *MockStorage) SaveSnapshot(snapshot *storage.Snapshot) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSnapshot", snapshot)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSnapshot indicates an expected call of SaveSnapshot.
+func (mr *MockStorageMockRecorder) SaveSnapshot(snapshot interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSnapshot", reflect.TypeOf((*MockStorage)(nil).SaveSnapshot), snapshot)
+}
@@ -0,0 +1,38 @@
+package storage
+
+// DefaultPrefix is the prefix redis keys are put under when WithPrefix isn't given
+const DefaultPrefix = "router-location-connector:"
+
+// Option specifies a builder function for configuring the redis storage
+type Option func(*Redis)
+
+// WithPrefix puts every key under the prefix, so several deployments or services can share a redis server
+func WithPrefix(prefix string) Option {
+	return func(r *Redis) {
+		r.prefix = prefix
+	}
+}
+
+// WithBatchSize sets how many records AddRouters and AddLocations write per pipeline, sizes below 1 are ignored
+func WithBatchSize(size int) Option {
+	return func(r *Redis) {
+		if size > 0 {
+			r.batchSize = size
+		}
+	}
+}
+
+// WithPoolSize sets the most connections the client opens, which bounds how many commands run concurrently.
+// Zero keeps the go-redis default of 10 per CPU
+func WithPoolSize(size int) Option {
+	return func(r *Redis) {
+		r.poolSize = size
+	}
+}
+
+// WithMinIdleConns keeps connections open while idle, so concurrent writers don't wait on new connections
+func WithMinIdleConns(conns int) Option {
+	return func(r *Redis) {
+		r.minIdleConns = conns
+	}
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"sort"
+	"time"
+
+	"router-location-connecter/api"
+)
+
+// _snapshotIDLayout makes snapshot IDs sort in the order they were created
+const _snapshotIDLayout = "20060102T150405.000Z"
+
+// SnapshotInfo describes a snapshot without its data
+type SnapshotInfo struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Routers   int       `json:"routers"`
+	Locations int       `json:"locations"`
+}
+
+// Snapshot is an immutable copy of the router location data of one ingest
+type Snapshot struct {
+	SnapshotInfo
+	Data api.RouterLocationData `json:"data"`
+}
+
+// NewSnapshot initializes a snapshot of the data, identified by its creation time
+func NewSnapshot(createdAt time.Time, data api.RouterLocationData) *Snapshot {
+	return &Snapshot{
+		SnapshotInfo: SnapshotInfo{
+			ID:        createdAt.UTC().Format(_snapshotIDLayout),
+			CreatedAt: createdAt.UTC(),
+			Routers:   len(data.Routers),
+			Locations: len(data.Locations),
+		},
+		Data: data,
+	}
+}
+
+// Retention decides which snapshots PruneSnapshots deletes, a zero value keeps every snapshot
+type Retention struct {
+	// Keep is how many of the newest snapshots are kept, zero doesn't limit the count
+	Keep int
+	// Before deletes snapshots created before this time, the zero time doesn't limit the age
+	Before time.Time
+}
+
+// expired returns the IDs of the snapshots the retention deletes
+func (r Retention) expired(infos []SnapshotInfo) []string {
+	sorted := append([]SnapshotInfo(nil), infos...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].CreatedAt.After(sorted[j].CreatedAt) })
+
+	var ids []string
+	for i, info := range sorted {
+		if (r.Keep > 0 && i >= r.Keep) || (!r.Before.IsZero() && info.CreatedAt.Before(r.Before)) {
+			ids = append(ids, info.ID)
+		}
+	}
+
+	return ids
+}
+
+// sortSnapshotInfos orders snapshots oldest first
+func sortSnapshotInfos(infos []SnapshotInfo) {
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].CreatedAt.Equal(infos[j].CreatedAt) {
+			return infos[i].CreatedAt.Before(infos[j].CreatedAt)
+		}
+		return infos[i].ID < infos[j].ID
+	})
+}
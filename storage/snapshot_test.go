@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+)
+
+func TestNewSnapshot(t *testing.T) {
+	createdAt := time.Date(2024, 3, 14, 23, 38, 46, 123e6, time.FixedZone("BST", 3600))
+
+	snapshot := NewSnapshot(createdAt, api.RouterLocationData{
+		Routers:   []api.Router{{ID: 1}, {ID: 2}},
+		Locations: []api.Location{{ID: 1}},
+	})
+
+	assert.Equal(t, SnapshotInfo{
+		ID:        "20240314T223846.123Z",
+		CreatedAt: createdAt.UTC(),
+		Routers:   2,
+		Locations: 1,
+	}, snapshot.SnapshotInfo)
+}
+
+func TestRetention_expired(t *testing.T) {
+	now := time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)
+	infos := []SnapshotInfo{
+		{ID: "c", CreatedAt: now.Add(-1 * time.Hour)},
+		{ID: "a", CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: "b", CreatedAt: now.Add(-2 * time.Hour)},
+	}
+
+	tests := []struct {
+		name      string
+		retention Retention
+		want      []string
+	}{
+		{
+			name:      "keeps everything by default",
+			retention: Retention{},
+		},
+		{
+			name:      "keeps the newest snapshots",
+			retention: Retention{Keep: 1},
+			want:      []string{"b", "a"},
+		},
+		{
+			name:      "deletes snapshots older than the cutoff",
+			retention: Retention{Before: now.Add(-150 * time.Minute)},
+			want:      []string{"a"},
+		},
+		{
+			name:      "applies both limits",
+			retention: Retention{Keep: 2, Before: now.Add(-90 * time.Minute)},
+			want:      []string{"b", "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.retention.expired(infos))
+		})
+	}
+}
+
+func TestMemory_Snapshots(t *testing.T) {
+	m := NewMemory()
+	now := time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)
+
+	older := NewSnapshot(now.Add(-time.Hour), api.RouterLocationData{
+		Routers: []api.Router{{ID: 1, Name: "citadel-01", LocationID: 1, RouterLinks: []int{1}}},
+	})
+	newer := NewSnapshot(now, api.RouterLocationData{
+		Locations: []api.Location{{ID: 1, Postcode: "BE12 2ND", Name: "Birmingham Motorcycle Museum"}},
+	})
+
+	assert.NoError(t, m.SaveSnapshot(newer))
+	assert.NoError(t, m.SaveSnapshot(older))
+	assert.ErrorIs(t, m.SaveSnapshot(older), ErrConflict)
+
+	// changing the saved data doesn't change the stored snapshot
+	older.Data.Routers[0].RouterLinks[0] = 99
+
+	infos, err := m.ListSnapshots()
+	assert.NoError(t, err)
+	assert.Equal(t, []SnapshotInfo{older.SnapshotInfo, newer.SnapshotInfo}, infos)
+
+	got, err := m.GetSnapshot(older.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, got.Data.Routers[0].RouterLinks)
+
+	// snapshots outlive clearing the routers, locations and links
+	assert.NoError(t, m.AddRouter(&api.Router{ID: 1}))
+	assert.NoError(t, m.Clear(context.Background()))
+
+	routers, err := m.ListRouters()
+	assert.NoError(t, err)
+	assert.Empty(t, routers)
+
+	pruned, err := m.PruneSnapshots(Retention{Keep: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{older.ID}, pruned)
+
+	_, err = m.GetSnapshot(older.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	infos, err = m.ListSnapshots()
+	assert.NoError(t, err)
+	assert.Equal(t, []SnapshotInfo{newer.SnapshotInfo}, infos)
+}
@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/nitishm/go-rejson/v4"
+	goredis "github.com/redis/go-redis/v9"
+
+	"router-location-connecter/api"
+)
+
+// Stager is implemented by storage able to ingest router location data atomically
+type Stager interface {
+	// Stage starts an empty staging area, nothing written to it is seen by readers of the storage until it's committed.
+	// The stage makes its commands with ctx
+	Stage(ctx context.Context) (Stage, error)
+	// Active returns storage for reading the routers, locations and links active now, making its commands with ctx.
+	// Commits made afterwards aren't seen through it, so a reader making several calls sees a single data set
+	Active(ctx context.Context) (Storage, error)
+}
+
+// Stage is a staging area for router location data. Snapshots aren't staged, they're read from and written to the
+// storage the stage was started from
+type Stage interface {
+	Storage
+	// Commit atomically replaces the routers, locations and links readers see with the staged ones. When it fails
+	// readers still see the data from before, so the stage can be discarded
+	Commit(ctx context.Context) error
+	// Discard deletes the staged data, the stage can't be used afterwards
+	Discard(ctx context.Context) error
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var (
+	_ Stager  = (*Redis)(nil)
+	_ Stage   = (*redisStage)(nil)
+	_ Storage = (*redisView)(nil)
+)
+
+// _commitScript points the active generation at the staged one, keeping the generation it replaces as the previous
+// generation. The generation previous until now is returned so it can be deleted
+var _commitScript = goredis.NewScript(`
+local expired = redis.call('GET', KEYS[2])
+local replaced = redis.call('GET', KEYS[1]) or ARGV[2]
+redis.call('SET', KEYS[1], ARGV[1])
+redis.call('SET', KEYS[2], replaced)
+return expired
+`)
+
+// redisStage writes to a new generation, committing it makes it the active generation
+type redisStage struct {
+	*Redis
+}
+
+// Stage starts a new generation
+func (r *Redis) Stage(ctx context.Context) (Stage, error) {
+	key := r.key(_generationSequenceKey)
+
+	id, err := r.Client.Incr(ctx, key).Result()
+	if err != nil {
+		return nil, redisError(key, err)
+	}
+
+	staged := r.withContext(ctx)
+	staged.gen = strconv.FormatInt(id, 10)
+
+	return &redisStage{Redis: staged}, nil
+}
+
+// redisView reads and writes the generation which was active when it was created
+type redisView struct {
+	*Redis
+}
+
+// Active looks up the active generation, a stage returns a view of itself
+func (r *Redis) Active(ctx context.Context) (Storage, error) {
+	g, err := r.active(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &redisView{Redis: g.withContext(ctx)}, nil
+}
+
+// Close leaves the client open as it's shared with the storage the view was created from
+func (v *redisView) Close() error {
+	return nil
+}
+
+func (s *redisStage) Commit(ctx context.Context) error {
+	keys := []string{s.key(_activeGenerationKey), s.key(_previousGenerationKey)}
+
+	expired, err := _commitScript.Run(ctx, s.Client, keys, s.gen, _initialGeneration).Text()
+	if errors.Is(err, goredis.Nil) {
+		return nil
+	}
+
+	if err != nil {
+		return redisError(keys[0], err)
+	}
+
+	// readers can't still be reading the generation from before the previous one. Failing to delete it only leaves it
+	// behind for Clear or Purge, so it doesn't fail the commit which has already happened
+	_ = s.generation(expired).Clear(ctx)
+
+	return nil
+}
+
+func (s *redisStage) Discard(ctx context.Context) error {
+	return s.Clear(ctx)
+}
+
+// Close leaves the client open as it's shared with the storage the stage was started from
+func (s *redisStage) Close() error {
+	return nil
+}
+
+// active looks up the active generation with ctx, returning the storage reading and writing it. A stage returns itself
+func (r *Redis) active(ctx context.Context) (*Redis, error) {
+	if r.gen != "" {
+		return r, nil
+	}
+
+	key := r.key(_activeGenerationKey)
+
+	gen, err := r.Client.Get(ctx, key).Result()
+	if errors.Is(err, goredis.Nil) {
+		// nothing has been committed, records are written straight to the initial generation
+		return r.generation(_initialGeneration), nil
+	}
+
+	if err != nil {
+		return nil, redisError(key, err)
+	}
+
+	return r.generation(gen), nil
+}
+
+// generation returns the storage reading and writing the given generation
+func (r *Redis) generation(gen string) *Redis {
+	g := *r
+	g.gen = gen
+
+	return &g
+}
+
+// withContext returns a copy of the storage making its commands, including those of the rejson handler, with ctx
+func (r *Redis) withContext(ctx context.Context) *Redis {
+	c := *r
+	c.ctx = ctx
+	c.Rh = rejson.NewReJSONHandler()
+	c.Rh.SetGoRedisClientWithContext(ctx, c.Client)
+
+	return &c
+}
+
+// Stage starts an empty staging area, committing it swaps its routers, locations and links into the store
+func (m *Memory) Stage(_ context.Context) (Stage, error) {
+	return &memoryStage{memoryView{
+		Memory: NewMemory().(*Memory),
+		parent: m,
+	}}, nil
+}
+
+// Active shares the routers, locations and links of the store with a view rather than copying them. Neither writes
+// to the shared maps, the store or view writing to them copies them first
+func (m *Memory) Active(_ context.Context) (Storage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.shared = true
+
+	view := &Memory{
+		routers:   m.routers,
+		locations: m.locations,
+		links:     m.links,
+		shared:    true,
+	}
+
+	return &memoryView{Memory: view, parent: m}, nil
+}
+
+// memoryView is a Memory holding its own routers, locations and links while snapshots are those of the parent
+type memoryView struct {
+	*Memory
+	parent *Memory
+}
+
+// memoryStage is a memoryView committed by swapping its records into the parent
+type memoryStage struct {
+	memoryView
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var (
+	_ Stager  = (*Memory)(nil)
+	_ Stage   = (*memoryStage)(nil)
+	_ Storage = (*memoryView)(nil)
+)
+
+func (s *memoryStage) Commit(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.parent.mu.Lock()
+	defer s.parent.mu.Unlock()
+
+	// the staged maps are swapped in whole so views of the data from before keep reading it unchanged
+	s.parent.routers, s.parent.locations, s.parent.links = s.routers, s.locations, s.links
+	s.parent.shared = false
+
+	// the maps now belong to the parent, the stage starts again empty should it be used by mistake
+	s.routers = make(map[int]api.Router)
+	s.locations = make(map[int]api.Location)
+	s.links = make(map[string]api.RouterLocationLink)
+
+	return nil
+}
+
+func (s *memoryStage) Discard(ctx context.Context) error {
+	return s.Clear(ctx)
+}
+
+func (v *memoryView) SaveSnapshot(snapshot *Snapshot) error {
+	return v.parent.SaveSnapshot(snapshot)
+}
+
+func (v *memoryView) ListSnapshots() ([]SnapshotInfo, error) {
+	return v.parent.ListSnapshots()
+}
+
+func (v *memoryView) GetSnapshot(id string) (*Snapshot, error) {
+	return v.parent.GetSnapshot(id)
+}
+
+func (v *memoryView) PruneSnapshots(retention Retention) ([]string, error) {
+	return v.parent.PruneSnapshots(retention)
+}
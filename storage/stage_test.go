@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+)
+
+func TestMemory_Stage(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	assert.NoError(t, m.AddRouter(&api.Router{ID: 1, Name: "meta-04", LocationID: 3}))
+
+	tests := []struct {
+		name        string
+		finish      func(stage Stage) error
+		wantRouters []api.Router
+	}{
+		{
+			name:        "discarding leaves the stored data as it was",
+			finish:      func(stage Stage) error { return stage.Discard(ctx) },
+			wantRouters: []api.Router{{ID: 1, Name: "meta-04", LocationID: 3}},
+		},
+		{
+			name:        "committing replaces the stored data with the staged data",
+			finish:      func(stage Stage) error { return stage.Commit(ctx) },
+			wantRouters: []api.Router{{ID: 2, Name: "universal-16", LocationID: 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stage, err := m.(Stager).Stage(ctx)
+			assert.NoError(t, err)
+
+			assert.NoError(t, stage.AddRouter(&api.Router{ID: 2, Name: "universal-16", LocationID: 3}))
+
+			// nothing staged is seen until it's committed
+			routers, err := m.ListRouters()
+			assert.NoError(t, err)
+			assert.Equal(t, []api.Router{{ID: 1, Name: "meta-04", LocationID: 3}}, routers)
+
+			assert.NoError(t, tt.finish(stage))
+
+			routers, err = m.ListRouters()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantRouters, routers)
+		})
+	}
+}
+
+func TestMemory_Stage_Snapshots(t *testing.T) {
+	m := NewMemory()
+
+	stage, err := m.(Stager).Stage(context.Background())
+	assert.NoError(t, err)
+
+	// snapshots aren't staged
+	snapshot := NewSnapshot(time.Date(2024, 3, 14, 23, 38, 46, 0, time.UTC), api.RouterLocationData{})
+	assert.NoError(t, stage.SaveSnapshot(snapshot))
+	assert.NoError(t, stage.Discard(context.Background()))
+
+	infos, err := m.ListSnapshots()
+	assert.NoError(t, err)
+	assert.Equal(t, []SnapshotInfo{snapshot.SnapshotInfo}, infos)
+}
+
+func TestMemory_Active(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	assert.NoError(t, m.AddRouter(&api.Router{ID: 1, Name: "meta-04", LocationID: 3}))
+
+	view, err := m.(Stager).Active(ctx)
+	assert.NoError(t, err)
+
+	stage, err := m.(Stager).Stage(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, stage.AddRouter(&api.Router{ID: 2, Name: "universal-16", LocationID: 3}))
+	assert.NoError(t, stage.Commit(ctx))
+
+	// the view keeps reading the data active when it was created
+	routers, err := view.ListRouters()
+	assert.NoError(t, err)
+	assert.Equal(t, []api.Router{{ID: 1, Name: "meta-04", LocationID: 3}}, routers)
+
+	routers, err = m.ListRouters()
+	assert.NoError(t, err)
+	assert.Equal(t, []api.Router{{ID: 2, Name: "universal-16", LocationID: 3}}, routers)
+}
+
+func TestMemory_Active_Writes(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	assert.NoError(t, m.AddRouter(&api.Router{ID: 1, Name: "meta-04", LocationID: 3}))
+
+	view, err := m.(Stager).Active(ctx)
+	assert.NoError(t, err)
+
+	// writing to the store or the view after sharing the data doesn't change what the other reads
+	assert.NoError(t, m.AddRouter(&api.Router{ID: 2, Name: "universal-16", LocationID: 3}))
+	assert.NoError(t, view.DeleteRouter(1))
+
+	routers, err := view.ListRouters()
+	assert.NoError(t, err)
+	assert.Empty(t, routers)
+
+	routers, err = m.ListRouters()
+	assert.NoError(t, err)
+	assert.Equal(t, []api.Router{
+		{ID: 1, Name: "meta-04", LocationID: 3},
+		{ID: 2, Name: "universal-16", LocationID: 3},
+	}, routers)
+}
+
+func TestRedis_Active_Context(t *testing.T) {
+	// nothing listens on the address, a cancelled context fails before a connection is attempted
+	r, err := New(context.Background(), "127.0.0.1:1", "")
+	assert.NoError(t, err)
+
+	defer func() {
+		_ = r.Close()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = r.(Stager).Active(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = r.(Stager).Stage(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
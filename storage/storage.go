@@ -3,38 +3,159 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/gomodule/redigo/redis"
 	"github.com/nitishm/go-rejson/v4"
+	"github.com/nitishm/go-rejson/v4/rjs"
 	goredis "github.com/redis/go-redis/v9"
 	"router-location-connecter/api"
 )
 
-// Storage is the interface for storage operations
+const (
+	_routerKeyPrefix   = "router_id_"
+	_locationKeyPrefix = "location_id_"
+	_linkKeyPrefix     = "link:"
+
+	// index sets of the stored IDs, so the records can be listed without scanning the keyspace
+	_routerIndexKey   = "router_ids"
+	_locationIndexKey = "location_ids"
+	_linkIndexKey     = "router_location_links"
+
+	// snapshots are kept under their own prefix, indexed by a sorted set scored by creation time
+	_snapshotKeyPrefix = "snapshot:"
+	_snapshotIndexKey  = "snapshots"
+
+	// routers, locations and links are kept in generations, _activeGenerationKey pointing at the one readers see while
+	// the next is staged. The generation replaced is kept until the following commit for readers still reading it
+	_generationKeyPrefix   = "gen:"
+	_activeGenerationKey   = "generation"
+	_previousGenerationKey = "generation:previous"
+	_generationSequenceKey = "generation:seq"
+	_initialGeneration     = "0"
+
+	// _purgeBatchSize is how many keys each SCAN asks for and each UNLINK deletes
+	_purgeBatchSize = 500
+)
+
+// Storage is the interface for storage operations.
+// Implementations wrap ErrNotFound, ErrConflict and ErrUnavailable so callers can use errors.Is regardless of backend
 type Storage interface {
 	AddRouter(router *api.Router) error
 	GetRouter(id int) (*api.Router, error)
 	AddLocation(location *api.Location) error
 	GetLocation(id int) (*api.Location, error)
+	// AddRouters and AddLocations write a batch of records, returning a *BatchError listing any which failed
+	AddRouters(routers []api.Router) error
+	AddLocations(locations []api.Location) error
 	AddRouterLocationLink(links *api.RouterLocationLink) error
 	GetRouterLocationLink(uniqueID string) (*api.RouterLocationLink, error)
-	FlushAll(ctx context.Context) error
+	// ListRouters returns every stored router ordered by ID
+	ListRouters() ([]api.Router, error)
+	// ListLocations returns every stored location ordered by ID
+	ListLocations() ([]api.Location, error)
+	// ListRouterLocationLinks returns every stored link ordered by source then destination location ID
+	ListRouterLocationLinks() ([]api.RouterLocationLink, error)
+	// DeleteRouter, DeleteLocation and DeleteRouterLocationLink succeed when there is nothing to delete
+	DeleteRouter(id int) error
+	DeleteLocation(id int) error
+	DeleteRouterLocationLink(uniqueID string) error
+	// SaveSnapshot stores a snapshot, returning ErrConflict if one with the same ID exists as snapshots are immutable
+	SaveSnapshot(snapshot *Snapshot) error
+	// ListSnapshots returns every stored snapshot oldest first
+	ListSnapshots() ([]SnapshotInfo, error)
+	GetSnapshot(id string) (*Snapshot, error)
+	// PruneSnapshots deletes the snapshots outside the retention, returning their IDs
+	PruneSnapshots(retention Retention) ([]string, error)
+	// Clear deletes the routers, locations and links, keeping snapshots
+	Clear(ctx context.Context) error
+	// Purge deletes everything written to storage, including snapshots
+	Purge(ctx context.Context) error
 	Close() error
 }
 
-// Redis is the implementation of Storage interface
+// Redis is the implementation of Storage interface.
+// Every key is put under the prefix so the server can be shared with other services
 type Redis struct {
 	Rh     *rejson.Handler
 	Client *goredis.Client
+	// ctx is used for commands made directly with the go-redis client, matching the context the rejson handler uses
+	ctx    context.Context
+	prefix string
+	// batchSize is how many records AddRouters and AddLocations write per pipeline
+	batchSize int
+	// gen is the generation routers, locations and links are read from and written to. It's only set on stages, the
+	// active generation is otherwise looked up for each operation
+	gen string
+	// poolSize and minIdleConns configure the go-redis connection pool, shared by concurrent writers
+	poolSize     int
+	minIdleConns int
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ Storage = (*Redis)(nil)
+
+// Purge deletes every key under the prefix, scanning for them rather than blocking the server with KEYS.
+// An empty prefix is refused as it would delete every key on the server
+func (r *Redis) Purge(ctx context.Context) error {
+	if r.prefix == "" {
+		return errors.New("purge requires a key prefix")
+	}
+
+	return r.unlinkMatching(ctx, escapeGlob(r.prefix)+"*")
 }
 
-func (r *Redis) FlushAll(ctx context.Context) error {
-	// Flush all data from the selected database
-	if err := r.Client.FlushAll(ctx).Err(); err != nil {
+// unlinkMatching deletes every key matching the SCAN pattern in batches
+func (r *Redis) unlinkMatching(ctx context.Context, match string) error {
+	iter := r.Client.Scan(ctx, 0, match, _purgeBatchSize).Iterator()
+
+	keys := make([]string, 0, _purgeBatchSize)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+
+		if len(keys) == _purgeBatchSize {
+			if err := r.Client.Unlink(ctx, keys...).Err(); err != nil {
+				return redisError(match, err)
+			}
+
+			keys = keys[:0]
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		return redisError(match, err)
+	}
+
+	if len(keys) > 0 {
+		if err := r.Client.Unlink(ctx, keys...).Err(); err != nil {
+			return redisError(match, err)
+		}
+	}
+
+	return nil
+}
+
+// Clear deletes the routers, locations and links of every generation, or only of the generation being staged
+func (r *Redis) Clear(ctx context.Context) error {
+	if r.gen != "" {
+		return r.unlinkMatching(ctx, escapeGlob(r.recordKey(""))+"*")
+	}
+
+	if err := r.unlinkMatching(ctx, escapeGlob(r.key(_generationKeyPrefix))+"*"); err != nil {
 		return err
 	}
 
+	keys := []string{r.key(_activeGenerationKey), r.key(_previousGenerationKey)}
+	if err := r.Client.Del(ctx, keys...).Err(); err != nil {
+		return redisError(keys[0], err)
+	}
+
 	return nil
 }
 
@@ -46,25 +167,41 @@ func (r *Redis) Close() error {
 	return nil
 }
 
+// AddRouterLocationLink only writes the link if it isn't already stored, returning ErrConflict otherwise
 func (r *Redis) AddRouterLocationLink(link *api.RouterLocationLink) error {
-	res, err := r.Rh.JSONSet(link.UniqueID, ".", link)
+	g, err := r.active(r.ctx)
 	if err != nil {
 		return err
 	}
 
-	if res.(string) != "OK" {
-		return err
+	key := g.recordKey(linkKey(link.UniqueID))
+
+	res, err := g.Rh.JSONSet(key, ".", link, rjs.SetOptionNX)
+	if err != nil {
+		return redisError(key, err)
 	}
 
-	return nil
+	// JSON.SET NX replies with nil when the key already exists
+	if res != "OK" {
+		return fmt.Errorf("key %s: %w", key, ErrConflict)
+	}
+
+	return g.index(_linkIndexKey, link.UniqueID)
 }
 
 func (r *Redis) GetRouterLocationLink(uniqueID string) (*api.RouterLocationLink, error) {
-	value, err := redis.Bytes(r.Rh.JSONGet(uniqueID, "."))
+	g, err := r.active(r.ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	key := g.recordKey(linkKey(uniqueID))
+
+	value, err := redis.Bytes(g.Rh.JSONGet(key, "."))
+	if err != nil {
+		return nil, redisError(key, err)
+	}
+
 	link := api.RouterLocationLink{}
 	if err = json.Unmarshal(value, &link); err != nil {
 		return nil, err
@@ -74,24 +211,38 @@ func (r *Redis) GetRouterLocationLink(uniqueID string) (*api.RouterLocationLink,
 }
 
 func (r *Redis) AddRouter(router *api.Router) error {
-	res, err := r.Rh.JSONSet("router_id_"+strconv.Itoa(router.ID), ".", router)
+	g, err := r.active(r.ctx)
 	if err != nil {
 		return err
 	}
 
-	if res.(string) != "OK" {
-		return err
+	key := g.recordKey(routerKey(router.ID))
+
+	res, err := g.Rh.JSONSet(key, ".", router)
+	if err != nil {
+		return redisError(key, err)
 	}
 
-	return nil
+	if res != "OK" {
+		return fmt.Errorf("key %s: unexpected reply %v", key, res)
+	}
+
+	return g.index(_routerIndexKey, strconv.Itoa(router.ID))
 }
 
 func (r *Redis) GetRouter(id int) (*api.Router, error) {
-	value, err := redis.Bytes(r.Rh.JSONGet("router_id_"+strconv.Itoa(id), "."))
+	g, err := r.active(r.ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	key := g.recordKey(routerKey(id))
+
+	value, err := redis.Bytes(g.Rh.JSONGet(key, "."))
+	if err != nil {
+		return nil, redisError(key, err)
+	}
+
 	router := api.Router{}
 	if err = json.Unmarshal(value, &router); err != nil {
 		return nil, err
@@ -101,24 +252,38 @@ func (r *Redis) GetRouter(id int) (*api.Router, error) {
 }
 
 func (r *Redis) AddLocation(location *api.Location) error {
-	res, err := r.Rh.JSONSet("location_id_"+strconv.Itoa(location.ID), ".", location)
+	g, err := r.active(r.ctx)
 	if err != nil {
 		return err
 	}
 
-	if res.(string) != "OK" {
-		return err
+	key := g.recordKey(locationKey(location.ID))
+
+	res, err := g.Rh.JSONSet(key, ".", location)
+	if err != nil {
+		return redisError(key, err)
 	}
 
-	return nil
+	if res != "OK" {
+		return fmt.Errorf("key %s: unexpected reply %v", key, res)
+	}
+
+	return g.index(_locationIndexKey, strconv.Itoa(location.ID))
 }
 
 func (r *Redis) GetLocation(id int) (*api.Location, error) {
-	value, err := redis.Bytes(r.Rh.JSONGet("location_id_"+strconv.Itoa(id), "."))
+	g, err := r.active(r.ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	key := g.recordKey(locationKey(id))
+
+	value, err := redis.Bytes(g.Rh.JSONGet(key, "."))
+	if err != nil {
+		return nil, redisError(key, err)
+	}
+
 	location := api.Location{}
 	if err = json.Unmarshal(value, &location); err != nil {
 		return nil, err
@@ -127,20 +292,381 @@ func (r *Redis) GetLocation(id int) (*api.Location, error) {
 	return &location, nil
 }
 
-func New(ctx context.Context, address, password string) (Storage, error) {
-	reJsonHandler := rejson.NewReJSONHandler()
+func (r *Redis) ListRouters() ([]api.Router, error) {
+	g, err := r.active(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	routers, err := listJSON[api.Router](g, _routerIndexKey, idKey(_routerKeyPrefix))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(routers, func(i, j int) bool { return routers[i].ID < routers[j].ID })
+
+	return routers, nil
+}
+
+func (r *Redis) ListLocations() ([]api.Location, error) {
+	g, err := r.active(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := listJSON[api.Location](g, _locationIndexKey, idKey(_locationKeyPrefix))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(locations, func(i, j int) bool { return locations[i].ID < locations[j].ID })
+
+	return locations, nil
+}
+
+func (r *Redis) ListRouterLocationLinks() ([]api.RouterLocationLink, error) {
+	g, err := r.active(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := listJSON[api.RouterLocationLink](g, _linkIndexKey, idKey(_linkKeyPrefix))
+	if err != nil {
+		return nil, err
+	}
+
+	SortRouterLocationLinks(links)
+
+	return links, nil
+}
+
+func (r *Redis) DeleteRouter(id int) error {
+	g, err := r.active(r.ctx)
+	if err != nil {
+		return err
+	}
+
+	return g.delete(routerKey(id), _routerIndexKey, strconv.Itoa(id))
+}
+
+func (r *Redis) DeleteLocation(id int) error {
+	g, err := r.active(r.ctx)
+	if err != nil {
+		return err
+	}
+
+	return g.delete(locationKey(id), _locationIndexKey, strconv.Itoa(id))
+}
+
+func (r *Redis) DeleteRouterLocationLink(uniqueID string) error {
+	g, err := r.active(r.ctx)
+	if err != nil {
+		return err
+	}
+
+	return g.delete(linkKey(uniqueID), _linkIndexKey, uniqueID)
+}
+
+// delete removes a key and its member from the index set in one transaction
+func (r *Redis) delete(key, indexKey, member string) error {
+	key, indexKey = r.recordKey(key), r.recordKey(indexKey)
+
+	_, err := r.Client.TxPipelined(r.ctx, func(pipe goredis.Pipeliner) error {
+		pipe.Del(r.ctx, key)
+		pipe.SRem(r.ctx, indexKey, member)
+
+		return nil
+	})
+	if err != nil {
+		return redisError(key, err)
+	}
+
+	return nil
+}
+
+// _saveSnapshotScript stores a snapshot, its info and its place in the index together, so a failure can't leave a
+// snapshot which isn't listed. Nothing is written and 0 is returned when the snapshot already exists
+var _saveSnapshotScript = goredis.NewScript(`
+if not redis.call('JSON.SET', KEYS[1], '.', ARGV[1], 'NX') then
+	return 0
+end
+redis.call('JSON.SET', KEYS[2], '.', ARGV[2])
+redis.call('ZADD', KEYS[3], ARGV[3], ARGV[4])
+return 1
+`)
+
+func (r *Redis) SaveSnapshot(snapshot *Snapshot) error {
+	key := r.key(snapshotKey(snapshot.ID))
+	keys := []string{key, r.key(snapshotInfoKey(snapshot.ID)), r.key(_snapshotIndexKey)}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	info, err := json.Marshal(snapshot.SnapshotInfo)
+	if err != nil {
+		return err
+	}
+
+	score := snapshot.CreatedAt.UnixMilli()
+
+	saved, err := _saveSnapshotScript.Run(r.ctx, r.Client, keys, data, info, score, snapshot.ID).Bool()
+	if err != nil {
+		return redisError(key, err)
+	}
+
+	if !saved {
+		return fmt.Errorf("key %s: %w", key, ErrConflict)
+	}
+
+	return nil
+}
+
+func (r *Redis) ListSnapshots() ([]SnapshotInfo, error) {
+	indexKey := r.key(_snapshotIndexKey)
+
+	ids, err := r.Client.ZRange(r.ctx, indexKey, 0, -1).Result()
+	if err != nil {
+		return nil, redisError(indexKey, err)
+	}
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, r.key(snapshotInfoKey(id)))
+	}
+
+	infos, err := mgetJSON[SnapshotInfo](r, indexKey, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	sortSnapshotInfos(infos)
+
+	return infos, nil
+}
+
+func (r *Redis) GetSnapshot(id string) (*Snapshot, error) {
+	key := r.key(snapshotKey(id))
+
+	value, err := redis.Bytes(r.Rh.JSONGet(key, "."))
+	if err != nil {
+		return nil, redisError(key, err)
+	}
+
+	snapshot := Snapshot{}
+	if err = json.Unmarshal(value, &snapshot); err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+func (r *Redis) PruneSnapshots(retention Retention) ([]string, error) {
+	infos, err := r.ListSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	expired := retention.expired(infos)
+	for _, id := range expired {
+		key := r.key(snapshotKey(id))
+
+		_, err := r.Client.TxPipelined(r.ctx, func(pipe goredis.Pipeliner) error {
+			pipe.Del(r.ctx, key, r.key(snapshotInfoKey(id)))
+			pipe.ZRem(r.ctx, r.key(_snapshotIndexKey), id)
+
+			return nil
+		})
+		if err != nil {
+			return nil, redisError(key, err)
+		}
+	}
+
+	return expired, nil
+}
+
+// index adds a member to an index set
+func (r *Redis) index(indexKey, member string) error {
+	indexKey = r.recordKey(indexKey)
+
+	if err := r.Client.SAdd(r.ctx, indexKey, member).Err(); err != nil {
+		return redisError(indexKey, err)
+	}
+
+	return nil
+}
+
+// listJSON reads the JSON value of every key in an index set, keys which no longer exist are skipped
+func listJSON[T any](r *Redis, indexKey string, keyFor func(member string) string) ([]T, error) {
+	indexKey = r.recordKey(indexKey)
+
+	members, err := r.Client.SMembers(r.ctx, indexKey).Result()
+	if err != nil {
+		return nil, redisError(indexKey, err)
+	}
+
+	keys := make([]string, 0, len(members))
+	for _, member := range members {
+		keys = append(keys, r.recordKey(keyFor(member)))
+	}
+
+	return mgetJSON[T](r, indexKey, keys)
+}
+
+// mgetJSON reads the JSON value of every key with a single JSON.MGET, keys which don't exist are skipped
+func mgetJSON[T any](r *Redis, indexKey string, keys []string) ([]T, error) {
+	items := make([]T, 0, len(keys))
+	if len(keys) == 0 {
+		return items, nil
+	}
+
+	res, err := r.Rh.JSONMGet(".", keys...)
+	if err != nil {
+		return nil, redisError(indexKey, err)
+	}
+
+	values, ok := res.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("key %s: unexpected reply %v", indexKey, res)
+	}
+
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+
+		var item T
+		if err := json.Unmarshal(value.([]byte), &item); err != nil {
+			return nil, fmt.Errorf("key %s: %w", keys[i], err)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// SortRouterLocationLinks orders links by source then destination location ID
+func SortRouterLocationLinks(links []api.RouterLocationLink) {
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].SourceID != links[j].SourceID {
+			return links[i].SourceID < links[j].SourceID
+		}
+		if links[i].DestinationID != links[j].DestinationID {
+			return links[i].DestinationID < links[j].DestinationID
+		}
+		return links[i].UniqueID < links[j].UniqueID
+	})
+}
+
+// redisError maps errors from either redis client library onto the storage sentinel errors, adding the key for context
+func redisError(key string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("key %s: %w", key, redisCause(err))
+}
+
+// redisCause maps an error onto the storage sentinel errors, keeping the original cause for anything but a missing key
+func redisCause(err error) error {
+	// go-redis replies with redis.Nil for a missing key whereas redigo's helpers return ErrNil
+	if errors.Is(err, goredis.Nil) || errors.Is(err, redis.ErrNil) {
+		return ErrNotFound
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, goredis.ErrClosed) || errors.Is(err, io.EOF) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+
+	return err
+}
+
+// key puts a key under the prefix
+func (r *Redis) key(key string) string {
+	return r.prefix + key
+}
+
+// recordKey puts the key of a router, location or link under the generation, the receiver must have it resolved
+func (r *Redis) recordKey(key string) string {
+	return r.key(_generationKeyPrefix + r.gen + ":" + key)
+}
+
+// escapeGlob escapes the characters SCAN MATCH treats as a pattern so a prefix is matched literally
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
+
+func routerKey(id int) string {
+	return _routerKeyPrefix + strconv.Itoa(id)
+}
+
+func locationKey(id int) string {
+	return _locationKeyPrefix + strconv.Itoa(id)
+}
+
+// linkKey keys a link by its unique ID, the sorted location names it joins
+func linkKey(uniqueID string) string {
+	return _linkKeyPrefix + uniqueID
+}
+
+func snapshotKey(id string) string {
+	return _snapshotKeyPrefix + id
+}
+
+func snapshotInfoKey(id string) string {
+	return _snapshotKeyPrefix + id + ":info"
+}
+
+// idKey returns a function converting an ID index member into the key of the record
+func idKey(prefix string) func(member string) string {
+	return func(member string) string {
+		return prefix + member
+	}
+}
+
+// New initializes the redis storage, keys are put under DefaultPrefix unless WithPrefix is given. A nil ctx is
+// treated as context.Background()
+func New(ctx context.Context, address, password string, opts ...Option) (Storage, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	r := &Redis{
+		ctx:       ctx,
+		prefix:    DefaultPrefix,
+		batchSize: DefaultBatchSize,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
 
-	client := goredis.NewClient(&goredis.Options{
-		Addr:     address, // Assuming Redis is running on localhost
-		Password: password,
+	// zero pool settings keep the go-redis defaults
+	r.Client = goredis.NewClient(&goredis.Options{
+		Addr:         address, // Assuming Redis is running on localhost
+		Password:     password,
+		PoolSize:     r.poolSize,
+		MinIdleConns: r.minIdleConns,
 	})
 
-	reJsonHandler.SetGoRedisClientWithContext(ctx, client)
+	r.Rh = rejson.NewReJSONHandler()
+	r.Rh.SetGoRedisClientWithContext(ctx, r.Client)
 
-	return &Redis{
-		Rh:     reJsonHandler,
-		Client: client,
-	}, nil
+	return r, nil
 }
 
 //func newPool(address, password string) *redis.Pool {
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_redisError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantIs  error
+		wantMsg string
+	}{
+		{
+			name:    "maps go-redis nil onto not found",
+			err:     goredis.Nil,
+			wantIs:  ErrNotFound,
+			wantMsg: "key router_id_1: not found",
+		},
+		{
+			name:    "maps redigo nil onto not found",
+			err:     redis.ErrNil,
+			wantIs:  ErrNotFound,
+			wantMsg: "key router_id_1: not found",
+		},
+		{
+			name:    "maps network errors onto unavailable",
+			err:     &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			wantIs:  ErrUnavailable,
+			wantMsg: "key router_id_1: storage unavailable: dial tcp: connection refused",
+		},
+		{
+			name:    "maps a closed connection onto unavailable",
+			err:     fmt.Errorf("read: %w", io.EOF),
+			wantIs:  ErrUnavailable,
+			wantMsg: "key router_id_1: storage unavailable: read: EOF",
+		},
+		{
+			name:    "maps a context deadline onto unavailable",
+			err:     context.DeadlineExceeded,
+			wantIs:  ErrUnavailable,
+			wantMsg: "key router_id_1: storage unavailable: context deadline exceeded",
+		},
+		{
+			name:    "wraps any other error with the key",
+			err:     errors.New("WRONGTYPE"),
+			wantMsg: "key router_id_1: WRONGTYPE",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := redisError(routerKey(1), tt.err)
+			if tt.wantIs != nil {
+				assert.ErrorIs(t, err, tt.wantIs)
+			}
+			if tt.wantIs != ErrNotFound {
+				// the original cause is kept for anything other than a plain miss
+				assert.ErrorIs(t, err, tt.err)
+			}
+			assert.Equal(t, tt.wantMsg, err.Error())
+		})
+	}
+
+	assert.NoError(t, redisError(routerKey(1), nil))
+}
+
+func TestRedis_key(t *testing.T) {
+	r := (&Redis{prefix: DefaultPrefix}).generation("3")
+
+	assert.Equal(t, "router-location-connector:gen:3:router_id_1", r.recordKey(routerKey(1)))
+	assert.Equal(t, "router-location-connector:gen:3:location_id_2", r.recordKey(locationKey(2)))
+	assert.Equal(t, "router-location-connector:gen:3:link:Lancaster Brewery:Lancaster University",
+		r.recordKey(linkKey("Lancaster Brewery:Lancaster University")))
+	assert.Equal(t, "router-location-connector:gen:3:router_ids", r.recordKey(_routerIndexKey))
+	// snapshots and the generation pointers are kept outside the generations
+	assert.Equal(t, "router-location-connector:snapshot:20240314T233846.000Z", r.key(snapshotKey("20240314T233846.000Z")))
+	assert.Equal(t, "router-location-connector:generation", r.key(_activeGenerationKey))
+}
+
+func Test_escapeGlob(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		want   string
+	}{
+		{name: "leaves a plain prefix as is", prefix: "router-location-connector:", want: "router-location-connector:"},
+		{name: "escapes pattern characters", prefix: `team[a]*?:\`, want: `team\[a\]\*\?:\\`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, escapeGlob(tt.prefix))
+		})
+	}
+}
+
+func TestRedis_Purge_EmptyPrefix(t *testing.T) {
+	r := &Redis{}
+
+	assert.EqualError(t, r.Purge(context.Background()), "purge requires a key prefix")
+}
@@ -0,0 +1,163 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Severity is how serious an issue is, only errors fail a run validated with Fail
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Mode decides what happens when router location data is validated during a run
+type Mode string
+
+const (
+	// Off skips validation
+	Off Mode = "off"
+	// Warn reports issues but carries on processing
+	Warn Mode = "warn"
+	// Fail reports issues and fails the run when there are any errors
+	Fail Mode = "fail"
+)
+
+// ParseMode converts a flag value to a Mode
+func ParseMode(mode string) (Mode, error) {
+	switch m := Mode(mode); m {
+	case Off, Warn, Fail:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown validate mode %q, must be one of %s|%s|%s", mode, Warn, Fail, Off)
+	}
+}
+
+// ParseFormat checks a flag value is a report format
+func ParseFormat(format string) (string, error) {
+	switch format {
+	case FormatText, FormatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown report format %q, must be one of %s|%s", format, FormatText, FormatJSON)
+	}
+}
+
+// Issue is a single data-quality problem found in router location data
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Check    Check    `json:"check"`
+	Kind     Kind     `json:"kind"`
+	// ID is the ID of the router or location the issue was found on
+	ID      int    `json:"id"`
+	Message string `json:"message"`
+}
+
+// Report lists the issues found validating router location data
+type Report struct {
+	Issues []Issue
+}
+
+// Errors returns how many issues have the error severity
+func (r *Report) Errors() int {
+	return r.count(SeverityError)
+}
+
+// Warnings returns how many issues have the warning severity
+func (r *Report) Warnings() int {
+	return r.count(SeverityWarning)
+}
+
+// Summary describes how many errors and warnings were found, e.g. "2 errors, 1 warning"
+func (r *Report) Summary() string {
+	return fmt.Sprintf("%s, %s", plural(r.Errors(), "error"), plural(r.Warnings(), "warning"))
+}
+
+// Write writes the report in the given format
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatText:
+		return r.WriteText(w)
+	case FormatJSON:
+		return r.WriteJSON(w)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+// WriteText writes one aligned line per issue followed by the summary
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	for _, issue := range r.Issues {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\n", issue.Severity, issue.Check, issue.Message); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintln(w, r.Summary())
+
+	return err
+}
+
+// WriteJSON writes the report as an indented JSON object with the error and warning counts
+func (r *Report) WriteJSON(w io.Writer) error {
+	issues := r.Issues
+	if issues == nil {
+		issues = []Issue{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(struct {
+		Errors   int     `json:"errors"`
+		Warnings int     `json:"warnings"`
+		Issues   []Issue `json:"issues"`
+	}{
+		Errors:   r.Errors(),
+		Warnings: r.Warnings(),
+		Issues:   issues,
+	})
+}
+
+func (r *Report) count(severity Severity) int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Error is returned when a run validated with Fail finds errors in the router location data
+type Error struct {
+	Report *Report
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("router location data failed validation: %s", e.Report.Summary())
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+
+	return fmt.Sprintf("%d %ss", n, noun)
+}
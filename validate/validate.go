@@ -0,0 +1,213 @@
+package validate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"router-location-connecter/api"
+)
+
+// Check identifies the data-quality rule an issue breaks
+type Check string
+
+const (
+	// CheckDuplicateRouterID is reported when more than one router has the same ID
+	CheckDuplicateRouterID Check = "duplicate-router-id"
+	// CheckDuplicateLocationID is reported when more than one location has the same ID
+	CheckDuplicateLocationID Check = "duplicate-location-id"
+	// CheckUnknownLocation is reported when a router's location ID doesn't reference an existing location
+	CheckUnknownLocation Check = "unknown-location"
+	// CheckUnknownRouterLink is reported when a router links to a router ID which doesn't exist
+	CheckUnknownRouterLink Check = "unknown-router-link"
+	// CheckSelfLink is reported when a router links to itself
+	CheckSelfLink Check = "self-link"
+	// CheckEmptyName is reported when a router or location has no name
+	CheckEmptyName Check = "empty-name"
+	// CheckInvalidPostcode is reported when a location's postcode isn't a well-formed UK postcode
+	CheckInvalidPostcode Check = "invalid-postcode"
+)
+
+// severities of each check, referential integrity and duplicate IDs change which links are output so they're errors
+var severities = map[Check]Severity{
+	CheckDuplicateRouterID:   SeverityError,
+	CheckDuplicateLocationID: SeverityError,
+	CheckUnknownLocation:     SeverityError,
+	CheckUnknownRouterLink:   SeverityError,
+	CheckSelfLink:            SeverityWarning,
+	CheckEmptyName:           SeverityWarning,
+	CheckInvalidPostcode:     SeverityWarning,
+}
+
+// Kind is the kind of record an issue is about
+type Kind string
+
+const (
+	KindRouter   Kind = "router"
+	KindLocation Kind = "location"
+)
+
+// _postcodePattern matches UK postcodes, with or without the space before the inward code
+var _postcodePattern = regexp.MustCompile(`^(GIR ?0AA|[A-Z]{1,2}[0-9][A-Z0-9]? ?[0-9][A-Z]{2})$`)
+
+// Validator checks router location data added one record at a time, e.g. while streaming it.
+// Only the IDs and references needed for the referential checks are kept
+type Validator struct {
+	routers   map[int]int
+	locations map[int]int
+	// locationRefs and routerLinks are keyed by the router making the reference
+	locationRefs map[int][]int
+	routerLinks  map[int][]int
+	issues       []Issue
+}
+
+// this is a check to confirm the implementation is compatible with dependent interfaces
+var _ api.Handler = (*Validator)(nil)
+
+// New initializes an empty validator
+func New() *Validator {
+	return &Validator{
+		routers:      make(map[int]int),
+		locations:    make(map[int]int),
+		locationRefs: make(map[int][]int),
+		routerLinks:  make(map[int][]int),
+	}
+}
+
+// Validate checks all of the router location data at once
+func Validate(data *api.RouterLocationData) *Report {
+	v := New()
+
+	for _, router := range data.Routers {
+		v.AddRouter(router)
+	}
+
+	for _, location := range data.Locations {
+		v.AddLocation(location)
+	}
+
+	return v.Report()
+}
+
+// AddRouter runs the checks needing only the router and records its references for the report
+func (v *Validator) AddRouter(router api.Router) {
+	v.routers[router.ID]++
+
+	if strings.TrimSpace(router.Name) == "" {
+		v.add(CheckEmptyName, KindRouter, router.ID, "router %d has no name", router.ID)
+	}
+
+	v.locationRefs[router.ID] = append(v.locationRefs[router.ID], router.LocationID)
+
+	for _, link := range router.RouterLinks {
+		if link == router.ID {
+			v.add(CheckSelfLink, KindRouter, router.ID, "router %d (%s) links to itself", router.ID, router.Name)
+			continue
+		}
+
+		v.routerLinks[router.ID] = append(v.routerLinks[router.ID], link)
+	}
+}
+
+// AddLocation runs the checks needing only the location and records its ID for the report
+func (v *Validator) AddLocation(location api.Location) {
+	v.locations[location.ID]++
+
+	if strings.TrimSpace(location.Name) == "" {
+		v.add(CheckEmptyName, KindLocation, location.ID, "location %d has no name", location.ID)
+	}
+
+	if !ValidPostcode(location.Postcode) {
+		v.add(CheckInvalidPostcode, KindLocation, location.ID, "location %d (%s) has malformed postcode %q",
+			location.ID, location.Name, location.Postcode)
+	}
+}
+
+// HandleRouter adds a router streamed by the api client
+func (v *Validator) HandleRouter(router *api.Router) error {
+	v.AddRouter(*router)
+
+	return nil
+}
+
+// HandleLocation adds a location streamed by the api client
+func (v *Validator) HandleLocation(location *api.Location) error {
+	v.AddLocation(*location)
+
+	return nil
+}
+
+// Report runs the checks needing every record and returns the issues found, ordered by severity, check, kind and ID
+func (v *Validator) Report() *Report {
+	issues := append([]Issue(nil), v.issues...)
+
+	for id, count := range v.routers {
+		if count > 1 {
+			issues = append(issues, newIssue(CheckDuplicateRouterID, KindRouter, id,
+				"router id %d is used by %d routers", id, count))
+		}
+	}
+
+	for id, count := range v.locations {
+		if count > 1 {
+			issues = append(issues, newIssue(CheckDuplicateLocationID, KindLocation, id,
+				"location id %d is used by %d locations", id, count))
+		}
+	}
+
+	for id, refs := range v.locationRefs {
+		for _, locationID := range refs {
+			if _, ok := v.locations[locationID]; !ok {
+				issues = append(issues, newIssue(CheckUnknownLocation, KindRouter, id,
+					"router %d references unknown location %d", id, locationID))
+			}
+		}
+	}
+
+	for id, links := range v.routerLinks {
+		for _, link := range links {
+			if _, ok := v.routers[link]; !ok {
+				issues = append(issues, newIssue(CheckUnknownRouterLink, KindRouter, id,
+					"router %d links to unknown router %d", id, link))
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		switch {
+		case a.Severity != b.Severity:
+			return a.Severity == SeverityError
+		case a.Check != b.Check:
+			return a.Check < b.Check
+		case a.Kind != b.Kind:
+			return a.Kind < b.Kind
+		case a.ID != b.ID:
+			return a.ID < b.ID
+		default:
+			return a.Message < b.Message
+		}
+	})
+
+	return &Report{Issues: issues}
+}
+
+// ValidPostcode reports whether the postcode is a well-formed UK postcode, ignoring case and surrounding spaces
+func ValidPostcode(postcode string) bool {
+	return _postcodePattern.MatchString(strings.ToUpper(strings.TrimSpace(postcode)))
+}
+
+func (v *Validator) add(check Check, kind Kind, id int, format string, args ...any) {
+	v.issues = append(v.issues, newIssue(check, kind, id, format, args...))
+}
+
+func newIssue(check Check, kind Kind, id int, format string, args ...any) Issue {
+	return Issue{
+		Severity: severities[check],
+		Check:    check,
+		Kind:     kind,
+		ID:       id,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
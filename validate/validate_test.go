@@ -0,0 +1,155 @@
+package validate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"router-location-connecter/api"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		data *api.RouterLocationData
+		want []Issue
+	}{
+		{
+			name: "reports nothing for valid data",
+			data: &api.RouterLocationData{
+				Routers: []api.Router{
+					{ID: 1, Name: "citadel-01", LocationID: 1, RouterLinks: []int{2}},
+					{ID: 2, Name: "citadel-02", LocationID: 2, RouterLinks: []int{1}},
+				},
+				Locations: []api.Location{
+					{ID: 1, Postcode: "BE12 2ND", Name: "Birmingham Motorcycle Museum"},
+					{ID: 2, Postcode: "la107qp", Name: "Lancaster University"},
+				},
+			},
+		},
+		{
+			name: "reports every broken check ordered by severity",
+			data: &api.RouterLocationData{
+				Routers: []api.Router{
+					{ID: 1, Name: "citadel-01", LocationID: 1, RouterLinks: []int{1, 99}},
+					{ID: 1, Name: "citadel-01b", LocationID: 1},
+					{ID: 2, Name: " ", LocationID: 7},
+				},
+				Locations: []api.Location{
+					{ID: 1, Postcode: "BE12", Name: "Birmingham Motorcycle Museum"},
+					{ID: 1, Postcode: "BE12 2ND", Name: ""},
+				},
+			},
+			want: []Issue{
+				{Severity: SeverityError, Check: CheckDuplicateLocationID, Kind: KindLocation, ID: 1, Message: "location id 1 is used by 2 locations"},
+				{Severity: SeverityError, Check: CheckDuplicateRouterID, Kind: KindRouter, ID: 1, Message: "router id 1 is used by 2 routers"},
+				{Severity: SeverityError, Check: CheckUnknownLocation, Kind: KindRouter, ID: 2, Message: "router 2 references unknown location 7"},
+				{Severity: SeverityError, Check: CheckUnknownRouterLink, Kind: KindRouter, ID: 1, Message: "router 1 links to unknown router 99"},
+				{Severity: SeverityWarning, Check: CheckEmptyName, Kind: KindLocation, ID: 1, Message: "location 1 has no name"},
+				{Severity: SeverityWarning, Check: CheckEmptyName, Kind: KindRouter, ID: 2, Message: "router 2 has no name"},
+				{Severity: SeverityWarning, Check: CheckInvalidPostcode, Kind: KindLocation, ID: 1, Message: `location 1 (Birmingham Motorcycle Museum) has malformed postcode "BE12"`},
+				{Severity: SeverityWarning, Check: CheckSelfLink, Kind: KindRouter, ID: 1, Message: "router 1 (citadel-01) links to itself"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Validate(tt.data).Issues)
+		})
+	}
+}
+
+func TestReport_Write(t *testing.T) {
+	report := &Report{Issues: []Issue{
+		{Severity: SeverityError, Check: CheckUnknownLocation, Kind: KindRouter, ID: 2, Message: "router 2 references unknown location 7"},
+		{Severity: SeverityWarning, Check: CheckSelfLink, Kind: KindRouter, ID: 1, Message: "router 1 (citadel-01) links to itself"},
+	}}
+
+	tests := []struct {
+		name    string
+		report  *Report
+		format  string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "text aligns issues and ends with a summary",
+			report: report,
+			format: FormatText,
+			want: "error    unknown-location  router 2 references unknown location 7\n" +
+				"warning  self-link         router 1 (citadel-01) links to itself\n" +
+				"1 error, 1 warning\n",
+		},
+		{
+			name:   "json includes the counts",
+			report: &Report{Issues: report.Issues[:1]},
+			format: FormatJSON,
+			want: `{
+  "errors": 1,
+  "warnings": 0,
+  "issues": [
+    {
+      "severity": "error",
+      "check": "unknown-location",
+      "kind": "router",
+      "id": 2,
+      "message": "router 2 references unknown location 7"
+    }
+  ]
+}
+`,
+		},
+		{
+			name:   "json writes an empty list when there are no issues",
+			report: &Report{},
+			format: FormatJSON,
+			want:   "{\n  \"errors\": 0,\n  \"warnings\": 0,\n  \"issues\": []\n}\n",
+		},
+		{
+			name:    "fails for an unknown format",
+			report:  report,
+			format:  "xml",
+			wantErr: `unknown report format "xml"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := tt.report.Write(&out, tt.format)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestValidPostcode(t *testing.T) {
+	for postcode, want := range map[string]bool{
+		"BE12 2ND": true,
+		"LA10 7QP": true,
+		"M1 1AE":   true,
+		"EC1A 1BB": true,
+		"ec1a1bb":  true,
+		"GIR 0AA":  true,
+		"":         false,
+		"BE12":     false,
+		"12AB 3CD": false,
+		"BE12 2N":  false,
+	} {
+		assert.Equal(t, want, ValidPostcode(postcode), postcode)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	mode, err := ParseMode("fail")
+	assert.NoError(t, err)
+	assert.Equal(t, Fail, mode)
+
+	_, err = ParseMode("strict")
+	assert.EqualError(t, err, `unknown validate mode "strict", must be one of warn|fail|off`)
+}